@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ogdakke/symbolista/internal/ignorer"
+	"github.com/ogdakke/symbolista/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var explainRoot string
+
+var explainCmd = &cobra.Command{
+	Use:   "explain PATH...",
+	Short: "Explain why paths are included in or ignored by the analysis",
+	Long: `Explain reports, for each path, whether an analysis of the root directory
+would count it, and which rule made the decision: an ignore file and line,
+the dotfile rule, an extension or file name rule, the special file check,
+the non-UTF-8 check or the size limit.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger.SetVerbosity(verboseCount)
+
+		matcher, err := ignorer.NewMatcherWithOptions(explainRoot, ignoreOptions())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		failed := false
+		for _, path := range args {
+			decision, err := matcher.Explain(explainRoot, path)
+			if err != nil {
+				fmt.Printf("%s: error: %v\n", path, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("%s: %s\n", path, decision)
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	explainCmd.Flags().StringVarP(&explainRoot, "root", "r", ".", "Directory the analysis would start from")
+	rootCmd.AddCommand(explainCmd)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplainCommand(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "cmd_explain_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	buildDir := filepath.Join(tempDir, "build")
+	if err := os.MkdirAll(buildDir, 0755); err != nil {
+		t.Fatalf("Failed to create build dir: %v", err)
+	}
+
+	files := map[string][]byte{
+		".gitignore":         []byte("# comment\n*.log\nbuild/\n"),
+		"main.go":            []byte("package main"),
+		"debug.log":          []byte("log"),
+		"image.svg":          []byte("<svg/>"),
+		"binary.bin":         {0xFF, 0xFE, 0x00},
+		"build/generated.go": []byte("package build"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), content, 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	originalRoot := explainRoot
+	originalDotfiles := includeDotfiles
+	explainRoot = tempDir
+	includeDotfiles = false
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	explainCmd.Run(explainCmd, []string{
+		filepath.Join(tempDir, "main.go"),
+		filepath.Join(tempDir, "debug.log"),
+		filepath.Join(tempDir, "image.svg"),
+		filepath.Join(tempDir, "binary.bin"),
+		filepath.Join(tempDir, "build", "generated.go"),
+		filepath.Join(tempDir, ".gitignore"),
+	})
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	explainRoot = originalRoot
+	includeDotfiles = originalDotfiles
+
+	expected := []string{
+		"main.go: included",
+		"debug.log: ignored by " + filepath.Join(tempDir, ".gitignore") + ":2 (*.log)",
+		"image.svg: ignored by extension rule (.svg)",
+		"binary.bin: ignored by non-UTF-8 content check",
+		"generated.go: ignored by " + filepath.Join(tempDir, ".gitignore") + ":3 (build/) via parent directory " + buildDir,
+		".gitignore: ignored by dotfile rule (use --include-dotfiles)",
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d: %v", len(expected), len(lines), lines)
+	}
+	for i, suffix := range expected {
+		if !strings.HasSuffix(lines[i], suffix) {
+			t.Errorf("Expected line %d to end with %q, got %q", i, suffix, lines[i])
+		}
+	}
+}
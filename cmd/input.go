@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ogdakke/symbolista/internal/counter"
+	"github.com/ogdakke/symbolista/internal/extract"
+	"github.com/ogdakke/symbolista/internal/history"
+	"github.com/ogdakke/symbolista/internal/output"
+)
+
+// stdinArg is the directory argument that reads text from stdin instead.
+const stdinArg = "-"
+
+// validateInputs checks that at most one input besides the directory walk is
+// selected, since each of them replaces the walk.
+func validateInputs(args []string) error {
+	if combinesRoots(args) {
+		if slices.Contains(args, stdinArg) {
+			return fmt.Errorf("stdin (-) cannot be combined with other directories or --history")
+		}
+		if useTUI {
+			return fmt.Errorf("--tui supports a single directory without --history")
+		}
+	}
+
+	var inputs []string
+	if len(args) == 1 && args[0] == stdinArg {
+		inputs = append(inputs, "stdin (-)")
+	}
+	if filesFrom != "" {
+		if len(args) > 0 && args[0] != stdinArg || rootsFile != "" || len(historyFiles) > 0 {
+			return fmt.Errorf("--files-from cannot be combined with a directory argument or --history")
+		}
+		inputs = append(inputs, "--files-from")
+	}
+	// --author blames the tracked files, so --git-tracked adds nothing to it
+	if gitTracked && len(authors) == 0 {
+		inputs = append(inputs, "--git-tracked")
+	}
+	if len(authors) > 0 {
+		inputs = append(inputs, "--author")
+	}
+	if gitDiffRange != "" {
+		inputs = append(inputs, "--git-diff")
+	}
+	if gitStaged || gitWorktree {
+		inputs = append(inputs, "--git-staged/--git-worktree")
+	}
+	if rev != "" {
+		inputs = append(inputs, "--rev")
+	}
+
+	if len(inputs) > 1 {
+		return fmt.Errorf("%s cannot be combined", strings.Join(inputs, " and "))
+	}
+	if dedupe || dedupeNormalize {
+		if len(args) == 1 && args[0] == stdinArg || len(authors) > 0 || gitDiffRange != "" || gitStaged || gitWorktree {
+			return fmt.Errorf("--dedupe needs whole files, so it cannot be used with stdin (-), --author or the --git-diff flags")
+		}
+	}
+	// Extractors parse whole files, so they cannot pick code blocks or cells
+	// out of piped text, history or the changed lines of a file
+	if markdownMode != string(extract.MarkdownAll) {
+		onlyHistory := len(historyFiles) > 0 && len(args) == 0 && rootsFile == ""
+		if len(args) == 1 && args[0] == stdinArg || onlyHistory || len(authors) > 0 || gitDiffRange != "" || gitStaged || gitWorktree {
+			return fmt.Errorf("--markdown needs whole files, so it cannot be used with stdin (-), only --history, --author or the --git-diff flags")
+		}
+	}
+	if nullSeparated && filesFrom == "" {
+		return fmt.Errorf("-0 requires --files-from")
+	}
+	if _, err := history.ParseFormat(historyFormat); err != nil {
+		return err
+	}
+	if _, err := extract.ParseMarkdownMode(markdownMode); err != nil {
+		return err
+	}
+	if err := chartOptions().Validate(); err != nil {
+		return err
+	}
+	if err := tableOptions().Validate(); err != nil {
+		return err
+	}
+	if err := filterOptions().Validate(); err != nil {
+		return err
+	}
+	targets, err := output.ParseTargets(outputFormats, outputPath)
+	if err != nil {
+		return err
+	}
+	hasTemplate := slices.ContainsFunc(targets, func(target output.Target) bool { return target.Format == "template" })
+	if templateFile == "" && hasTemplate {
+		return fmt.Errorf("--format template requires --template")
+	}
+	if templateFile != "" && !hasTemplate {
+		return fmt.Errorf("--template is only used by the template format; add it to --format, e.g. --format table --format template:report.txt")
+	}
+	return nil
+}
+
+// readInputs reads text piped on stdin or the --files-from list into options.
+func readInputs(options *counter.Options, dir string) error {
+	if dir == stdinArg {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("could not read stdin: %w", err)
+		}
+		if data == nil {
+			data = []byte{}
+		}
+		options.Stdin = data
+	}
+
+	if filesFrom != "" {
+		paths, err := readFileList(filesFrom, nullSeparated)
+		if err != nil {
+			return err
+		}
+		options.Files = paths
+	}
+
+	return nil
+}
+
+// readFileList reads paths separated by newlines, or by NUL bytes as printed
+// by find -print0, git ls-files -z and fd -0. "-" reads the list from stdin.
+func readFileList(path string, nullSeparated bool) ([]string, error) {
+	var data []byte
+	var err error
+	if path == stdinArg {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read file list: %w", err)
+	}
+
+	separator := []byte{'\n'}
+	if nullSeparated {
+		separator = []byte{0}
+	}
+
+	paths := []string{}
+	for entry := range bytes.SplitSeq(data, separator) {
+		if !nullSeparated {
+			entry = bytes.TrimSuffix(entry, []byte{'\r'})
+		}
+		if len(entry) == 0 {
+			continue
+		}
+		paths = append(paths, string(entry))
+	}
+	return paths, nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadFileList(t *testing.T) {
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name          string
+		content       string
+		nullSeparated bool
+		expected      []string
+	}{
+		{"newlines", "main.go\nsrc/a b.go\n\n", false, []string{"main.go", "src/a b.go"}},
+		{"crlf", "main.go\r\nsrc/a.go\r\n", false, []string{"main.go", "src/a.go"}},
+		{"nul", "main.go\x00with\nnewline.go\x00", true, []string{"main.go", "with\nnewline.go"}},
+		{"empty", "", false, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listPath := filepath.Join(tempDir, tt.name)
+			if err := os.WriteFile(listPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write file list: %v", err)
+			}
+
+			paths, err := readFileList(listPath, tt.nullSeparated)
+			if err != nil {
+				t.Fatalf("readFileList failed: %v", err)
+			}
+			if paths == nil || !slices.Equal(paths, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, paths)
+			}
+		})
+	}
+
+	if _, err := readFileList(filepath.Join(tempDir, "missing"), false); err == nil {
+		t.Error("Expected an error for a missing file list")
+	}
+}
+
+func TestValidateInputs(t *testing.T) {
+	defer func() {
+		filesFrom, nullSeparated, gitTracked, authors, rev = "", false, false, nil, ""
+		markdownMode, gitStaged, historyFiles = "all", false, nil
+		templateFile, outputFormats = "", []string{"table"}
+	}()
+
+	tests := []struct {
+		name  string
+		setup func()
+		args  []string
+		valid bool
+	}{
+		{"directory", func() {}, []string{"."}, true},
+		{"stdin", func() {}, []string{"-"}, true},
+		{"files from", func() { filesFrom = "list.txt"; nullSeparated = true }, nil, true},
+		{"files from with directory", func() { filesFrom = "list.txt" }, []string{"."}, false},
+		{"stdin with files from", func() { filesFrom = "-" }, []string{"-"}, false},
+		{"stdin with rev", func() { rev = "HEAD" }, []string{"-"}, false},
+		{"null without files from", func() { nullSeparated = true }, []string{"."}, false},
+		{"author with git tracked", func() { authors = []string{"me@example.com"}; gitTracked = true }, []string{"."}, true},
+		{"markdown with directory", func() { markdownMode = "code-only" }, []string{"."}, true},
+		{"markdown with stdin", func() { markdownMode = "code-only" }, []string{"-"}, false},
+		{"markdown with git staged", func() { markdownMode = "prose-only"; gitStaged = true }, []string{"."}, false},
+		{"markdown with author", func() { markdownMode = "code-only"; authors = []string{"me@example.com"} }, []string{"."}, false},
+		{"markdown with only history", func() { markdownMode = "code-only"; historyFiles = []string{"h"} }, nil, false},
+		{"markdown with history and directory", func() { markdownMode = "code-only"; historyFiles = []string{"h"} }, []string{"."}, true},
+		{"template format without template", func() { outputFormats = []string{"template"} }, []string{"."}, false},
+		{"template with template format", func() { templateFile = "t.tmpl"; outputFormats = []string{"table", "template:out.txt"} }, []string{"."}, true},
+		{"template without template format", func() { templateFile = "t.tmpl"; outputFormats = []string{"json"} }, []string{"."}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filesFrom, nullSeparated, gitTracked, authors, rev = "", false, false, nil, ""
+			markdownMode, gitStaged, historyFiles = "all", false, nil
+			templateFile, outputFormats = "", []string{"table"}
+			tt.setup()
+
+			err := validateInputs(tt.args)
+			if (err == nil) != tt.valid {
+				t.Errorf("Expected valid=%v, got error %v", tt.valid, err)
+			}
+		})
+	}
+}
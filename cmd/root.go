@@ -3,9 +3,17 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/charmbracelet/x/term"
+	"github.com/ogdakke/symbolista/internal/concurrent"
 	"github.com/ogdakke/symbolista/internal/counter"
+	"github.com/ogdakke/symbolista/internal/extract"
+	"github.com/ogdakke/symbolista/internal/git"
+	"github.com/ogdakke/symbolista/internal/history"
+	"github.com/ogdakke/symbolista/internal/ignorer"
 	"github.com/ogdakke/symbolista/internal/logger"
 	"github.com/ogdakke/symbolista/internal/output"
 	"github.com/ogdakke/symbolista/internal/tui"
@@ -15,7 +23,8 @@ import (
 const Version = "v0.2.0"
 
 var (
-	outputFormat    string
+	outputFormats   []string
+	outputPath      string
 	showPercentages bool
 	verboseCount    int
 	workerCount     int
@@ -27,14 +36,59 @@ var (
 	jsonFile        string
 	topNSeq         int
 	countSequences  bool
+	gitTracked      bool
+	extensions      []string
+	excludeExts     []string
+	presets         []string
+	maxFileSize     int64
+	ignoreFileNames []string
+	vendorDirs      []string
+	linguist        bool
+	gitDiffRange    string
+	gitStaged       bool
+	gitWorktree     bool
+	authors         []string
+	rev             string
+	filesFrom       string
+	nullSeparated   bool
+	rootsFile       string
+	historyFiles    []string
+	historyFormat   string
+	historyDedupe   bool
+	markdownMode    string
+	dedupe          bool
+	dedupeNormalize bool
+	chartKind       string
+	chartItems      string
+	chartLabels     string
+	chartTop        int
+	tableBars       string
+	tableColor      string
+	templateFile    string
+	onlyClass       string
+	excludeSpace    bool
+	relativePercent bool
+	minCount        int
+	minPercent      float64
+	topNChars       int
+	charOrder       string
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "symbolista [directory]",
+	Use:   "symbolista [directory[:weight]... | archive | -]",
 	Short: "Count symbols and characters in a codebase",
 	Long: `Symbolista recursively counts symbols and characters in a codebase,
-respecting gitignore rules and outputting the most used characters with counts and percentages.`,
-	Args: cobra.MaximumNArgs(1),
+respecting gitignore rules and outputting the most used characters with counts and percentages.
+
+A zip or tar archive (.zip, .tar, .tar.gz, .tgz) is analyzed like a directory.
+Use "-" as the directory to count text piped on stdin, or --files-from to count
+an explicit list of files from git ls-files, fd or find.
+
+Several directories, or a --roots file listing them, are analyzed with their own
+ignore files and combined. A weight after a colon scales a directory's counts,
+e.g. symbolista ~/work ~/hobby:2. --history adds the commands of a bash, zsh or
+fish history file to the combination.`,
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		if showVersion {
 			fmt.Println(Version)
@@ -46,7 +100,17 @@ respecting gitignore rules and outputting the most used characters with counts a
 			os.Exit(1)
 		}
 
-		if jsonFile == "" && len(args) == 0 {
+		// --template alone selects the template format
+		if templateFile != "" && !cmd.Flags().Changed("format") {
+			outputFormats = []string{"template"}
+		}
+
+		if err := validateInputs(args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonFile == "" && len(args) == 0 && filesFrom == "" && !combinesRoots(args) {
 			cmd.Help()
 			return
 		}
@@ -54,9 +118,24 @@ respecting gitignore rules and outputting the most used characters with counts a
 		startTime := time.Now()
 		logger.SetVerbosity(verboseCount)
 
-		dir := "."
-		if len(args) > 0 {
-			dir = args[0]
+		dir, err := singleDirectory(args)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		roots, err := collectRoots(args)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		options := analysisOptions()
+		if jsonFile == "" {
+			if err := readInputs(&options, dir); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
 		}
 
 		if useTUI {
@@ -69,8 +148,8 @@ respecting gitignore rules and outputting the most used characters with counts a
 				}
 				return
 			}
-			logger.Info("Starting TUI mode", "directory", dir, "verbosity", verboseCount, "workers", workerCount, "includeDotfiles", includeDotfiles, "asciiOnly", asciiOnly, "topNSeq", topNSeq)
-			err := tui.RunTUI(dir, showPercentages, workerCount, includeDotfiles, asciiOnly, topNSeq, countSequences)
+			logger.Info("Starting TUI mode", "directory", dir, "verbosity", verboseCount, "workers", workerCount, "includeDotfiles", includeDotfiles, "asciiOnly", asciiOnly, "topNSeq", topNSeq, "gitTracked", gitTracked, "presets", presets)
+			err := tui.RunTUI(dir, showPercentages, options)
 			if err != nil {
 				fmt.Printf("TUI error: %v\n", err)
 				os.Exit(1)
@@ -78,22 +157,46 @@ respecting gitignore rules and outputting the most used characters with counts a
 			return
 		}
 
-		logger.Info("Starting symbol analysis", "directory", dir, "format", outputFormat, "verbosity", verboseCount, "workers", workerCount, "includeDotfiles", includeDotfiles, "asciiOnly", asciiOnly, "topNSeq", topNSeq)
+		targets, err := output.ParseTargets(outputFormats, outputPath)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		outputter := output.NewOutputter(os.Stdout)
+		outputter.Chart = chartOptions()
+		outputter.Table = tableOptions()
+		outputter.Filter = filterOptions()
+		outputter.ToolVersion = Version
+		if templateFile != "" {
+			if outputter.Template, err = output.ParseTemplate(templateFile); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 
-		outputter := output.NewOutputter()
+		if roots != nil {
+			logger.Info("Starting symbol analysis of several directories", "roots", len(roots), "formats", outputFormats)
+			if err := counter.CountRootsConcurrent(outputter, roots, targets, showPercentages, includeMetadata, options); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		logger.Info("Starting symbol analysis", "directory", dir, "formats", outputFormats, "verbosity", verboseCount, "workers", workerCount, "includeDotfiles", includeDotfiles, "asciiOnly", asciiOnly, "topNSeq", topNSeq, "gitTracked", gitTracked, "presets", presets)
 
-		counter.CountSymbolsConcurrent(
+		err = counter.CountSymbolsConcurrent(
 			outputter,
 			dir,
-			outputFormat,
+			targets,
 			showPercentages,
-			workerCount,
-			includeDotfiles,
-			asciiOnly,
 			includeMetadata,
-			topNSeq,
-			countSequences,
+			options,
 		)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 
 		totalExecutionTime := time.Since(startTime)
 		if verboseCount > 0 {
@@ -102,6 +205,97 @@ respecting gitignore rules and outputting the most used characters with counts a
 	},
 }
 
+func analysisOptions() counter.Options {
+	return counter.Options{
+		WorkerCount:    workerCount,
+		AsciiOnly:      asciiOnly,
+		TopNSeq:        topNSeq,
+		SequenceConfig: counter.NewSequenceConfig(countSequences),
+		Ignore:         ignoreOptions(),
+		GitTracked:     gitTracked,
+		GitDiff:        gitDiffSpec(),
+		Authors:        authors,
+		Rev:            rev,
+		History:        history.Options{Format: history.Format(historyFormat), Dedupe: historyDedupe},
+		Extractors:     extract.Default(extract.MarkdownMode(markdownMode)),
+		Dedupe:         dedupeMode(),
+	}
+}
+
+func dedupeMode() concurrent.DedupeMode {
+	switch {
+	case dedupeNormalize:
+		return concurrent.DedupeNormalized
+	case dedupe:
+		return concurrent.DedupeExact
+	}
+	return concurrent.DedupeOff
+}
+
+func chartOptions() output.ChartOptions {
+	return output.ChartOptions{
+		Kind:   output.ChartKind(chartKind),
+		Items:  output.ChartItems(chartItems),
+		Labels: output.ChartLabel(chartLabels),
+		Top:    chartTop,
+	}
+}
+
+func filterOptions() output.Filter {
+	return output.Filter{
+		Only:                output.CharClass(onlyClass),
+		ExcludeWhitespace:   excludeSpace,
+		RelativePercentages: relativePercent,
+		MinCount:            minCount,
+		MinPercent:          minPercent,
+		TopN:                topNChars,
+		Sort:                output.CharOrder(charOrder),
+	}
+}
+
+// tableOptions enables the terminal features of the table format when stdout
+// is a terminal, unless NO_COLOR is set for colors.
+func tableOptions() output.TableOptions {
+	opts := output.TableOptions{
+		Bars:  output.TableMode(tableBars),
+		Color: output.TableMode(tableColor),
+	}
+	if opts.Color == output.TableAuto && os.Getenv("NO_COLOR") != "" {
+		opts.Color = output.TableNever
+	}
+
+	if term.IsTerminal(os.Stdout.Fd()) {
+		opts.Terminal = true
+		if width, _, err := term.GetSize(os.Stdout.Fd()); err == nil {
+			opts.Width = width
+		}
+	} else if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil {
+		opts.Width = columns
+	}
+	return opts
+}
+
+func gitDiffSpec() git.DiffSpec {
+	return git.DiffSpec{
+		Range:    gitDiffRange,
+		Staged:   gitStaged,
+		Worktree: gitWorktree,
+	}
+}
+
+func ignoreOptions() ignorer.Options {
+	return ignorer.Options{
+		IncludeDotfiles:    includeDotfiles,
+		Extensions:         extensions,
+		ExcludeExtensions:  excludeExts,
+		Presets:            presets,
+		MaxFileSize:        maxFileSize,
+		IgnoreFileNames:    ignoreFileNames,
+		VendorDirs:         vendorDirs,
+		LinguistAttributes: linguist,
+	}
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -111,15 +305,52 @@ func Execute() {
 
 func init() {
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "Show version and exit")
-	rootCmd.Flags().StringVarP(&outputFormat, "format", "f", "table", "Output format (table, json, csv)")
+	rootCmd.Flags().StringArrayVarP(&outputFormats, "format", "f", []string{"table"}, "Output format ("+strings.Join(output.Formats, ", ")+"), repeatable; format:PATH writes it to a file and format:- to stdout")
+	rootCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write the output to this file instead of stdout")
+	rootCmd.Flags().StringVar(&chartKind, "chart", string(output.ChartBars), "Chart drawn by --format svg (bars, pareto)")
+	rootCmd.Flags().StringVar(&chartItems, "chart-items", string(output.ChartCharacters), "What --format svg charts (characters, sequences)")
+	rootCmd.Flags().StringVar(&chartLabels, "chart-labels", string(output.ChartLabelCount), "Labels above the --format svg bars (count, percentage)")
+	rootCmd.Flags().IntVar(&chartTop, "chart-top", output.DefaultChartTop, "Number of bars in --format svg charts")
+	rootCmd.Flags().StringVar(&onlyClass, "only", "", "Only output characters of a class (letters, digits, symbols, whitespace), and sequences containing one")
+	rootCmd.Flags().BoolVar(&excludeSpace, "exclude-whitespace", false, "Leave whitespace characters and whitespace-only sequences out of the output")
+	rootCmd.Flags().BoolVar(&relativePercent, "relative-percentages", false, "Compute percentages from the characters and sequences left by --only and --exclude-whitespace")
+	rootCmd.Flags().IntVar(&minCount, "min-count", 0, "Leave characters used fewer times out of the output")
+	rootCmd.Flags().Float64Var(&minPercent, "min-percent", 0, "Leave characters with a smaller percentage out of the output")
+	rootCmd.Flags().IntVar(&topNChars, "top-n-chars", 0, "Maximum number of characters to display (0 = all)")
+	rootCmd.Flags().StringVar(&charOrder, "sort", string(output.OrderCount), "Order of the characters (count, char, codepoint)")
+	rootCmd.Flags().StringVar(&templateFile, "template", "", "Render the results with a Go text/template file, as the template format")
+	rootCmd.Flags().StringVar(&tableBars, "bars", string(output.TableAuto), "Draw bars in the table format (auto, always, never); auto draws them on a terminal")
+	rootCmd.Flags().StringVar(&tableColor, "color", string(output.TableAuto), "Color the table format (auto, always, never); auto colors it on a terminal unless NO_COLOR is set")
 	rootCmd.Flags().BoolVarP(&showPercentages, "percentages", "p", true, "Show percentages in output")
-	rootCmd.Flags().CountVarP(&verboseCount, "verbose", "V", "Increase verbosity (-V info, -VV debug, -VVV trace)")
+	rootCmd.PersistentFlags().CountVarP(&verboseCount, "verbose", "V", "Increase verbosity (-V info, -VV debug, -VVV trace)")
 	rootCmd.Flags().IntVarP(&workerCount, "workers", "w", 0, "Number of worker goroutines (0 = auto-detect based on CPU cores)")
-	rootCmd.Flags().BoolVar(&includeDotfiles, "include-dotfiles", false, "Include dotfiles in analysis (default false)")
+	rootCmd.PersistentFlags().BoolVar(&includeDotfiles, "include-dotfiles", false, "Include dotfiles in analysis (default false)")
 	rootCmd.Flags().BoolVar(&asciiOnly, "ascii-only", true, "Count only ASCII characters. Use --ascii-only=false to include all Unicode characters")
 	rootCmd.Flags().BoolVar(&useTUI, "tui", false, "Launch interactive TUI interface")
 	rootCmd.Flags().BoolVarP(&includeMetadata, "metadata", "m", true, "Include metadata in JSON output (directory, file counts, timing info)")
 	rootCmd.Flags().StringVarP(&jsonFile, "from-json", "j", "", "Load data from JSON file and launch TUI (requires --tui flag)")
 	rootCmd.Flags().IntVarP(&topNSeq, "top-n-seq", "N", 100, "Maximum number of sequences to display")
 	rootCmd.Flags().BoolVarP(&countSequences, "count-sequences", "c", true, "Count sequences")
+	rootCmd.PersistentFlags().StringSliceVar(&extensions, "ext", nil, "Only count files with these extensions, e.g. --ext go,ts,rs")
+	rootCmd.PersistentFlags().StringSliceVar(&excludeExts, "exclude-ext", nil, "Skip files with these extensions, e.g. --exclude-ext json,lock")
+	rootCmd.PersistentFlags().StringSliceVar(&presets, "preset", nil, "Apply built-in file rules ("+strings.Join(ignorer.PresetNames(), ", ")+")")
+	rootCmd.PersistentFlags().Int64Var(&maxFileSize, "max-file-size", 0, "Skip files larger than this many bytes (0 = no limit)")
+	rootCmd.PersistentFlags().StringArrayVar(&ignoreFileNames, "ignore-file-name", nil, "Ignore file to read in each directory, repeatable; later names take precedence (default "+strings.Join(ignorer.DefaultIgnoreFileNames, ", ")+")")
+	rootCmd.PersistentFlags().StringSliceVar(&vendorDirs, "vendor-dir", ignorer.DefaultVendorDirs, "Skip directories with these names wherever they appear; --vendor-dir= counts them")
+	rootCmd.PersistentFlags().BoolVar(&linguist, "linguist", true, "Skip files .gitattributes marks as linguist-vendored, linguist-generated or linguist-documentation")
+	rootCmd.Flags().BoolVar(&gitTracked, "git-tracked", false, "Count only files tracked in the git index, instead of walking the directory with gitignore rules")
+	rootCmd.Flags().StringVar(&gitDiffRange, "git-diff", "", "Count only lines added by the commits in a revision range, e.g. --git-diff main..HEAD")
+	rootCmd.Flags().StringVar(&filesFrom, "files-from", "", "Count the files listed in this file, one per line (\"-\" reads the list from stdin)")
+	rootCmd.Flags().BoolVarP(&nullSeparated, "null", "0", false, "Files in the --files-from list are separated by NUL bytes, as printed by find -print0")
+	rootCmd.Flags().StringVar(&markdownMode, "markdown", string(extract.MarkdownAll), "Which parts of Markdown files and notebook cells to count (all, code-only, prose-only)")
+	rootCmd.Flags().BoolVar(&dedupe, "dedupe", false, "Count each distinct file content once, skipping copies of files already counted")
+	rootCmd.Flags().BoolVar(&dedupeNormalize, "dedupe-normalize", false, "Like --dedupe, but files that only differ in line endings or trailing whitespace are copies too")
+	rootCmd.Flags().StringVar(&rootsFile, "roots", "", "Combine the directories listed in this file, one \"directory[:weight]\" per line")
+	rootCmd.Flags().StringArrayVar(&historyFiles, "history", nil, "Count the commands of a shell history file, e.g. ~/.zsh_history:0.5, repeatable; combined with any directories")
+	rootCmd.Flags().StringVar(&historyFormat, "history-format", string(history.FormatAuto), "Format of --history files (auto, bash, zsh, fish)")
+	rootCmd.Flags().BoolVar(&historyDedupe, "history-dedupe", false, "Count each distinct --history command once")
+	rootCmd.Flags().StringVar(&rev, "rev", "", "Analyze the files of a git revision (tag, branch or commit) without checking it out")
+	rootCmd.Flags().StringArrayVar(&authors, "author", nil, "Count only lines git blame attributes to this author email, repeatable")
+	rootCmd.Flags().BoolVar(&gitStaged, "git-staged", false, "Count only lines added by staged changes")
+	rootCmd.Flags().BoolVar(&gitWorktree, "git-worktree", false, "Count only lines added by unstaged working tree changes and untracked files (with --git-staged: all uncommitted changes)")
 }
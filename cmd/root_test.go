@@ -3,8 +3,11 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -24,12 +27,12 @@ func TestExecuteWithDefaultArgs(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
-	originalFormat := outputFormat
+	originalFormats := outputFormats
 	originalPercentages := showPercentages
 	originalVerbosity := verboseCount
 	originalArgs := os.Args
 
-	outputFormat = "json"
+	outputFormats = []string{"json"}
 	showPercentages = true
 	verboseCount = 0
 
@@ -47,7 +50,7 @@ func TestExecuteWithDefaultArgs(t *testing.T) {
 	buf.ReadFrom(r)
 	output := buf.String()
 
-	outputFormat = originalFormat
+	outputFormats = originalFormats
 	showPercentages = originalPercentages
 	verboseCount = originalVerbosity
 	os.Args = originalArgs
@@ -86,11 +89,11 @@ func TestExecuteWithDirectoryArg(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
-	originalFormat := outputFormat
+	originalFormats := outputFormats
 	originalPercentages := showPercentages
 	originalVerbosity := verboseCount
 
-	outputFormat = "json"
+	outputFormats = []string{"json"}
 	showPercentages = false
 	verboseCount = 0
 
@@ -107,7 +110,7 @@ func TestExecuteWithDirectoryArg(t *testing.T) {
 	buf.ReadFrom(r)
 	output := buf.String()
 
-	outputFormat = originalFormat
+	outputFormats = originalFormats
 	showPercentages = originalPercentages
 	verboseCount = originalVerbosity
 
@@ -145,12 +148,12 @@ func TestExecuteWithCSVFormat(t *testing.T) {
 	}
 
 	// Save original values
-	originalFormat := outputFormat
+	originalFormats := outputFormats
 	originalPercentages := showPercentages
 	originalVerbosity := verboseCount
 
 	// Set test values
-	outputFormat = "csv"
+	outputFormats = []string{"csv"}
 	showPercentages = true
 	verboseCount = 0
 
@@ -170,7 +173,7 @@ func TestExecuteWithCSVFormat(t *testing.T) {
 	output := buf.String()
 
 	// Restore original values
-	outputFormat = originalFormat
+	outputFormats = originalFormats
 	showPercentages = originalPercentages
 	verboseCount = originalVerbosity
 
@@ -201,12 +204,12 @@ func TestExecuteWithTableFormat(t *testing.T) {
 	}
 
 	// Save original values
-	originalFormat := outputFormat
+	originalFormats := outputFormats
 	originalPercentages := showPercentages
 	originalVerbosity := verboseCount
 
 	// Set test values
-	outputFormat = "table"
+	outputFormats = []string{"table"}
 	showPercentages = true
 	verboseCount = 0
 
@@ -226,7 +229,7 @@ func TestExecuteWithTableFormat(t *testing.T) {
 	output := buf.String()
 
 	// Restore original values
-	outputFormat = originalFormat
+	outputFormats = originalFormats
 	showPercentages = originalPercentages
 	verboseCount = originalVerbosity
 
@@ -272,12 +275,12 @@ func TestExecuteWithGitignore(t *testing.T) {
 	}
 
 	// Save original values
-	originalFormat := outputFormat
+	originalFormats := outputFormats
 	originalPercentages := showPercentages
 	originalVerbosity := verboseCount
 
 	// Set test values
-	outputFormat = "json"
+	outputFormats = []string{"json"}
 	showPercentages = false
 	verboseCount = 0
 
@@ -297,7 +300,7 @@ func TestExecuteWithGitignore(t *testing.T) {
 	output := buf.String()
 
 	// Restore original values
-	outputFormat = originalFormat
+	outputFormats = originalFormats
 	showPercentages = originalPercentages
 	verboseCount = originalVerbosity
 
@@ -343,12 +346,12 @@ func TestExecuteWithVerbosity(t *testing.T) {
 	}
 
 	// Save original values
-	originalFormat := outputFormat
+	originalFormats := outputFormats
 	originalPercentages := showPercentages
 	originalVerbosity := verboseCount
 
 	// Set test values
-	outputFormat = "json"
+	outputFormats = []string{"json"}
 	showPercentages = false
 	verboseCount = 2 // Debug level
 
@@ -375,7 +378,7 @@ func TestExecuteWithVerbosity(t *testing.T) {
 	stderr := bufErr.String()
 
 	// Restore original values
-	outputFormat = originalFormat
+	outputFormats = originalFormats
 	showPercentages = originalPercentages
 	verboseCount = originalVerbosity
 
@@ -392,42 +395,67 @@ func TestExecuteWithVerbosity(t *testing.T) {
 }
 
 func TestExecuteWithNonExistentDirectory(t *testing.T) {
-	// Save original values
-	originalFormat := outputFormat
-	originalVerbosity := verboseCount
+	stdout, stderr, exitCode := runCommand(t, "--format", "json", "/nonexistent/directory")
 
-	// Set test values
-	outputFormat = "json"
-	verboseCount = 0
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr, "Error:") {
+		t.Errorf("Expected error message for nonexistent directory on stderr, got %q", stderr)
+	}
+	if strings.Contains(stdout, "Error") {
+		t.Errorf("Expected no error message on stdout, got %q", stdout)
+	}
+}
 
-	// Capture stdout and stderr
-	oldStdout := os.Stdout
-	oldStderr := os.Stderr
-	rOut, wOut, _ := os.Pipe()
-	rErr, wErr, _ := os.Pipe()
-	os.Stdout = wOut
-	os.Stderr = wErr
+func TestExecuteWithInvalidGitDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
 
-	// Execute the command
-	rootCmd.Run(rootCmd, []string{"/nonexistent/directory"})
+	dir := t.TempDir()
+	git := exec.Command("git", "init", "-q")
+	git.Dir = dir
+	if out, err := git.CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
 
-	wOut.Close()
-	wErr.Close()
-	os.Stdout = oldStdout
-	os.Stderr = oldStderr
+	_, stderr, exitCode := runCommand(t, "--git-diff", "does-not-exist..HEAD", dir)
 
-	var bufOut, bufErr bytes.Buffer
-	bufOut.ReadFrom(rOut)
-	bufErr.ReadFrom(rErr)
-	stdout := bufOut.String()
-	stderr := bufErr.String()
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1 for an invalid range, got %d", exitCode)
+	}
+	if !strings.Contains(stderr, "Error: ") || !strings.Contains(stderr, "does-not-exist") {
+		t.Errorf("Expected the git error on stderr, got %q", stderr)
+	}
+}
 
-	// Restore original values
-	outputFormat = originalFormat
-	verboseCount = originalVerbosity
+// runCommand runs the CLI with args in a new process of the test binary, so
+// that its os.Exit can be observed.
+func runCommand(t *testing.T, args ...string) (stdout, stderr string, exitCode int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=^TestCommandProcess$", "--"}, args...)...)
+	cmd.Env = append(os.Environ(), "SYMBOLISTA_COMMAND_PROCESS=1")
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("Failed to run command: %v", err)
+	}
+	return outBuf.String(), errBuf.String(), cmd.ProcessState.ExitCode()
+}
 
-	combined := stdout + stderr
-	if !strings.Contains(combined, "Error") && !strings.Contains(combined, "error") {
-		t.Error("Expected error message for nonexistent directory")
+// TestCommandProcess is the process started by runCommand.
+func TestCommandProcess(t *testing.T) {
+	if os.Getenv("SYMBOLISTA_COMMAND_PROCESS") != "1" {
+		t.Skip("only run by runCommand")
 	}
+	args := os.Args[slices.Index(os.Args, "--")+1:]
+	os.Args = append([]string{"symbolista"}, args...)
+	Execute()
+	os.Exit(0)
 }
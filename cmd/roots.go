@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ogdakke/symbolista/internal/counter"
+)
+
+// combinesRoots reports whether the arguments select several directories or
+// history files to combine, instead of analyzing a single directory.
+func combinesRoots(args []string) bool {
+	return len(args) > 1 || rootsFile != "" || len(historyFiles) > 0
+}
+
+// singleDirectory returns the directory to analyze when the arguments do not
+// combine roots. A weight, as in "src:2", is accepted like with several
+// directories, but has nothing to be weighed against.
+func singleDirectory(args []string) (string, error) {
+	if len(args) == 0 {
+		return ".", nil
+	}
+	root, err := parseRoot(args[0])
+	if err != nil {
+		return "", err
+	}
+	return root.Path, nil
+}
+
+// collectRoots returns the directories and history files to combine, or nil
+// when a single directory is analyzed.
+func collectRoots(args []string) ([]counter.Root, error) {
+	if !combinesRoots(args) {
+		return nil, nil
+	}
+
+	var roots []counter.Root
+	for _, arg := range args {
+		root, err := parseRoot(arg)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+
+	if rootsFile != "" {
+		listed, err := readRootsManifest(rootsFile)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, listed...)
+	}
+
+	for _, arg := range historyFiles {
+		root, err := parseRoot(arg)
+		if err != nil {
+			return nil, err
+		}
+		root.History = true
+		roots = append(roots, root)
+	}
+
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("%s lists no directories", rootsFile)
+	}
+	return roots, nil
+}
+
+// parseRoot parses a directory with an optional weight, "dir" or "dir:2.5".
+// A suffix after the last colon that is not a number is part of the path.
+func parseRoot(arg string) (counter.Root, error) {
+	root := counter.Root{Path: arg, Weight: 1}
+	i := strings.LastIndex(arg, ":")
+	if i <= 0 {
+		return root, nil
+	}
+
+	weight, err := strconv.ParseFloat(arg[i+1:], 64)
+	if err != nil {
+		return root, nil
+	}
+	if !(weight > 0) || math.IsInf(weight, 1) {
+		return counter.Root{}, fmt.Errorf("weight of %s must be a positive number, got %s", arg[:i], arg[i+1:])
+	}
+	return counter.Root{Path: arg[:i], Weight: weight}, nil
+}
+
+// readRootsManifest reads one "dir" or "dir:weight" per line. Blank lines and
+// lines starting with # are skipped, ~/ is the home directory, and relative
+// paths are relative to the manifest.
+func readRootsManifest(path string) ([]counter.Root, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read roots file: %w", err)
+	}
+	defer file.Close()
+
+	var roots []counter.Root
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		root, err := parseRoot(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		if rest, ok := strings.CutPrefix(root.Path, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+			}
+			root.Path = filepath.Join(home, rest)
+		} else if !filepath.IsAbs(root.Path) {
+			root.Path = filepath.Join(filepath.Dir(path), root.Path)
+		}
+		roots = append(roots, root)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read roots file: %w", err)
+	}
+	return roots, nil
+}
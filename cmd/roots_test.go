@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ogdakke/symbolista/internal/counter"
+)
+
+func TestParseRoot(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected counter.Root
+		wantErr  bool
+	}{
+		{"src", counter.Root{Path: "src", Weight: 1}, false},
+		{"~/b:2.0", counter.Root{Path: "~/b", Weight: 2}, false},
+		{"a:b:0.5", counter.Root{Path: "a:b", Weight: 0.5}, false},
+		{"dir:name", counter.Root{Path: "dir:name", Weight: 1}, false},
+		{":3", counter.Root{Path: ":3", Weight: 1}, false},
+		{"src:0", counter.Root{}, true},
+		{"src:-1", counter.Root{}, true},
+		{"src:NaN", counter.Root{}, true},
+	}
+
+	for _, tt := range tests {
+		root, err := parseRoot(tt.arg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRoot(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			continue
+		}
+		if root != tt.expected {
+			t.Errorf("parseRoot(%q) = %+v, want %+v", tt.arg, root, tt.expected)
+		}
+	}
+}
+
+func TestSingleDirectory(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{nil, ".", false},
+		{[]string{"-"}, "-", false},
+		{[]string{"src"}, "src", false},
+		{[]string{"src:2"}, "src", false},
+		{[]string{"dir:name"}, "dir:name", false},
+		{[]string{"src:0"}, "", true},
+	}
+
+	for _, tt := range tests {
+		dir, err := singleDirectory(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("singleDirectory(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if dir != tt.expected {
+			t.Errorf("singleDirectory(%q) = %q, want %q", tt.args, dir, tt.expected)
+		}
+	}
+}
+
+func TestReadRootsManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	manifest := filepath.Join(tempDir, "roots.txt")
+	content := "# personal repos\nwork\n\n  /abs/hobby:2.5  \n"
+	if err := os.WriteFile(manifest, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	roots, err := readRootsManifest(manifest)
+	if err != nil {
+		t.Fatalf("readRootsManifest failed: %v", err)
+	}
+	expected := []counter.Root{
+		{Path: filepath.Join(tempDir, "work"), Weight: 1},
+		{Path: "/abs/hobby", Weight: 2.5},
+	}
+	if !slices.Equal(roots, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, roots)
+	}
+
+	if err := os.WriteFile(manifest, []byte("work:-2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if _, err := readRootsManifest(manifest); err == nil {
+		t.Error("Expected an error for a negative weight")
+	}
+}
@@ -0,0 +1,167 @@
+// Package archive opens zip and tar archives as an io/fs.FS, so they can be
+// analyzed like a directory without extracting them.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/ogdakke/symbolista/internal/logger"
+	"github.com/ogdakke/symbolista/internal/vfs"
+)
+
+// Extensions are the archive file extensions Open supports.
+var Extensions = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// Archive is an opened archive. Close releases the underlying file.
+type Archive struct {
+	fs.FS
+	closer io.Closer
+}
+
+func (a *Archive) Close() error {
+	return a.closer.Close()
+}
+
+// IsArchive reports whether path names a supported archive, by its extension.
+func IsArchive(path string) bool {
+	lower := strings.ToLower(path)
+	for _, ext := range Extensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Open opens the archive at path. Zip and uncompressed tar contents are read
+// from the file on demand; gzipped tar archives are decompressed into memory,
+// since they can only be read from start to end.
+func Open(path string) (*Archive, error) {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		return &Archive{FS: reader, closer: reader}, nil
+	case strings.HasSuffix(lower, ".tar"):
+		return openTar(path, false)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return openTar(path, true)
+	}
+	return nil, fmt.Errorf("unsupported archive %s (supported: %s)", path, strings.Join(Extensions, ", "))
+}
+
+func openTar(path string, gzipped bool) (*Archive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fsys *vfs.FS
+	if gzipped {
+		fsys, err = readGzipTar(file)
+	} else {
+		fsys, err = indexTar(file)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	return &Archive{FS: fsys, closer: file}, nil
+}
+
+func readGzipTar(file *os.File) (*vfs.FS, error) {
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	fsys := vfs.New()
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return fsys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var read vfs.ReadFunc
+		if header.Typeflag == tar.TypeReg {
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				return nil, err
+			}
+			read = func() ([]byte, error) { return content, nil }
+		}
+		addTarEntry(fsys, header, read)
+	}
+}
+
+// indexTar lists an uncompressed tar archive and records where each file's
+// content starts, so it can be read from the file later.
+func indexTar(file *os.File) (*vfs.FS, error) {
+	counter := &countingReader{reader: file}
+	fsys := vfs.New()
+	reader := tar.NewReader(counter)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return fsys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var read vfs.ReadFunc
+		if header.Typeflag == tar.TypeReg {
+			section := io.NewSectionReader(file, counter.offset, header.Size)
+			read = func() ([]byte, error) {
+				content := make([]byte, section.Size())
+				_, err := section.ReadAt(content, 0)
+				return content, err
+			}
+		}
+		addTarEntry(fsys, header, read)
+	}
+}
+
+func addTarEntry(fsys *vfs.FS, header *tar.Header, read vfs.ReadFunc) {
+	info := header.FileInfo()
+	switch header.Typeflag {
+	case tar.TypeDir:
+		fsys.AddDir(header.Name, header.ModTime)
+		return
+	case tar.TypeReg, tar.TypeSymlink:
+	default:
+		logger.Trace("Skipping archive entry", "path", header.Name, "type", string(header.Typeflag))
+		return
+	}
+
+	if !fsys.Add(header.Name, header.Size, info.Mode(), header.ModTime, read) {
+		logger.Debug("Skipping archive entry with an invalid path", "path", header.Name)
+	}
+}
+
+type countingReader struct {
+	reader io.Reader
+	offset int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
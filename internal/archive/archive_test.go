@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+var testFiles = map[string]string{
+	".gitignore":       "*.log\n",
+	"main.go":          "package main\n",
+	"src/util.go":      "package src\n",
+	"src/deep/note.md": "# note\n",
+}
+
+func writeZip(t *testing.T, path string) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for name, content := range testFiles {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, content)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTar(t *testing.T, path string, gzipped bool) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var out io.Writer = file
+	if gzipped {
+		gz := gzip.NewWriter(file)
+		defer gz.Close()
+		out = gz
+	}
+
+	writer := tar.NewWriter(out)
+	for name, content := range testFiles {
+		header := &tar.Header{Name: "./" + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(writer, content)
+	}
+	writer.WriteHeader(&tar.Header{Name: "link.go", Linkname: "main.go", Typeflag: tar.TypeSymlink})
+	writer.WriteHeader(&tar.Header{Name: "../escape.go", Mode: 0644, Typeflag: tar.TypeReg})
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		write func(path string)
+	}{
+		{"src.zip", func(path string) { writeZip(t, path) }},
+		{"src.tar", func(path string) { writeTar(t, path, false) }},
+		{"src.tar.gz", func(path string) { writeTar(t, path, true) }},
+		{"src.TGZ", func(path string) { writeTar(t, path, true) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.name)
+			tt.write(path)
+
+			if !IsArchive(path) {
+				t.Fatalf("Expected %s to be an archive", path)
+			}
+			archive, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer archive.Close()
+
+			for name, content := range testFiles {
+				data, err := fs.ReadFile(archive, name)
+				if err != nil {
+					t.Errorf("ReadFile(%s) failed: %v", name, err)
+					continue
+				}
+				if string(data) != content {
+					t.Errorf("ReadFile(%s) = %q, want %q", name, data, content)
+				}
+			}
+
+			if _, err := fs.Stat(archive, "escape.go"); err == nil {
+				t.Error("Expected the entry outside the archive root to be skipped")
+			}
+		})
+	}
+}
+
+func TestTarFS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "src.tar")
+	writeTar(t, path, false)
+
+	archive, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer archive.Close()
+
+	info, err := fs.Stat(archive, "link.go")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("Expected link.go to be a symlink, got mode %v", info.Mode())
+	}
+
+	// The symlink cannot be read, so test the regular files only
+	if err := fstest.TestFS(archive, ".gitignore", "main.go", "src/util.go", "src/deep/note.md"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"src.zip":    true,
+		"src.tar.gz": true,
+		"src.tgz":    true,
+		"src.tar":    true,
+		"src.gz":     false,
+		"src":        false,
+		"main.go":    false,
+	} {
+		if IsArchive(path) != expected {
+			t.Errorf("IsArchive(%q) = %v, want %v", path, !expected, expected)
+		}
+	}
+}
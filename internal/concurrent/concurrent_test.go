@@ -1,12 +1,16 @@
 package concurrent
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sync"
 	"testing"
+	"testing/fstest"
 
+	"github.com/ogdakke/symbolista/internal/extract"
 	"github.com/ogdakke/symbolista/internal/ignorer"
 )
 
@@ -198,7 +202,7 @@ func TestDiscoverFiles(t *testing.T) {
 		MaxLength: 3,
 		Threshold: 2,
 	}
-	go DiscoverFiles(tmpDir, matcher, jobChan, true, sequenceConfig, collector, nil, func(err error) {
+	go DiscoverFiles(os.DirFS(tmpDir), tmpDir, matcher, jobChan, true, sequenceConfig, nil, collector, nil, func(err error) {
 		discoveryError = err
 	})
 
@@ -227,3 +231,111 @@ func TestDiscoverFiles(t *testing.T) {
 		t.Errorf("Expected content2, got %s", contentMap[testFile2])
 	}
 }
+
+func TestDiscoverFilesFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":        {Data: []byte("*.log\nbuild/\n")},
+		"main.go":           {Data: []byte("package main")},
+		"debug.log":         {Data: []byte("ignored")},
+		"build/out.go":      {Data: []byte("ignored")},
+		"src/util.go":       {Data: []byte("package src")},
+		"src/link.go":       {Data: []byte("main.go"), Mode: fs.ModeSymlink},
+		"src/.gitignore":    {Data: []byte("gen.go\n")},
+		"src/gen.go":        {Data: []byte("ignored")},
+		"src/nested/doc.md": {Data: []byte("# doc")},
+	}
+
+	rootPath := "archive.zip"
+	matcher, err := ignorer.NewMatcherWithOptions(rootPath, ignorer.Options{FS: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jobChan := make(chan FileJob, 10)
+	collector := NewResultCollector()
+	go DiscoverFiles(fsys, rootPath, matcher, jobChan, true, SequenceConfig{}, nil, collector, nil, func(err error) {
+		t.Errorf("Discovery error: %v", err)
+	})
+
+	var paths []string
+	for job := range jobChan {
+		paths = append(paths, job.Path)
+	}
+	slices.Sort(paths)
+
+	expected := []string{
+		filepath.Join(rootPath, "main.go"),
+		filepath.Join(rootPath, "src", "nested", "doc.md"),
+		filepath.Join(rootPath, "src", "util.go"),
+	}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+}
+
+func TestDiscoverFileList(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "discover_list_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	listed := filepath.Join(tmpDir, "listed.txt")
+	unlisted := filepath.Join(tmpDir, "unlisted.txt")
+	missing := filepath.Join(tmpDir, "missing.txt")
+
+	if err := os.WriteFile(listed, []byte("listed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(unlisted, []byte("unlisted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	jobChan := make(chan FileJob, 10)
+	collector := NewResultCollector()
+	go DiscoverFileList([]string{listed, missing}, nil, jobChan, true, SequenceConfig{}, nil, collector, nil, nil)
+
+	var jobs []FileJob
+	for job := range jobChan {
+		jobs = append(jobs, job)
+	}
+
+	if len(jobs) != 1 || jobs[0].Path != listed {
+		t.Fatalf("Expected only %s to be queued, got %v", listed, jobs)
+	}
+
+	_, _, _, _, _, filesFound, filesIgnored, _ := collector.GetResults()
+	if filesFound != 2 || filesIgnored != 1 {
+		t.Errorf("Expected 2 found and 1 ignored, got %d found and %d ignored", filesFound, filesIgnored)
+	}
+}
+
+func TestDeduper(t *testing.T) {
+	exact := NewDeduper(DedupeExact)
+	if !exact.FirstSeen(FileJob{Content: []byte("a = 1\n")}) {
+		t.Error("first file should be counted")
+	}
+	if exact.FirstSeen(FileJob{Content: []byte("a = 1\n")}) {
+		t.Error("identical file should be a duplicate")
+	}
+	if !exact.FirstSeen(FileJob{Content: []byte("a = 1\r\n")}) {
+		t.Error("CRLF copy should not be an exact duplicate")
+	}
+	if !exact.FirstSeen(FileJob{Content: []byte("a = 1\n"), Extractor: extract.Markdown{}}) {
+		t.Error("files read by another extractor should not be duplicates")
+	}
+
+	normalized := NewDeduper(DedupeNormalized)
+	normalized.FirstSeen(FileJob{Content: []byte("a = 1\nb = 2\n")})
+	if normalized.FirstSeen(FileJob{Content: []byte("a = 1  \r\nb = 2\t\r\n\r\n")}) {
+		t.Error("copy with other line endings and trailing whitespace should be a duplicate")
+	}
+	if !normalized.FirstSeen(FileJob{Content: []byte("a  = 1\nb = 2\n")}) {
+		t.Error("whitespace inside lines should still matter")
+	}
+
+	var off *Deduper = NewDeduper(DedupeOff)
+	if off != nil || !off.FirstSeen(FileJob{Content: []byte("a")}) || !off.FirstSeen(FileJob{Content: []byte("a")}) {
+		t.Error("a nil Deduper should count every file")
+	}
+}
@@ -0,0 +1,81 @@
+package concurrent
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sync"
+)
+
+// DedupeMode selects how files are compared when duplicates are skipped.
+type DedupeMode string
+
+const (
+	DedupeOff DedupeMode = ""
+	// DedupeExact skips files whose bytes were already counted
+	DedupeExact DedupeMode = "exact"
+	// DedupeNormalized also treats files as equal when they only differ in
+	// line endings, trailing whitespace or trailing blank lines
+	DedupeNormalized DedupeMode = "normalized"
+)
+
+// Deduper remembers the content hashes of the files counted so far. It is
+// shared by the workers of a pool, which hash files in parallel.
+type Deduper struct {
+	mode DedupeMode
+	seen map[[sha256.Size]byte]bool
+	mu   sync.Mutex
+}
+
+// NewDeduper returns a Deduper for the mode, or nil when mode is DedupeOff.
+func NewDeduper(mode DedupeMode) *Deduper {
+	if mode == DedupeOff {
+		return nil
+	}
+	return &Deduper{
+		mode: mode,
+		seen: make(map[[sha256.Size]byte]bool),
+	}
+}
+
+// FirstSeen reports whether the job's content is counted for the first time.
+// Files handled by different extractors are never duplicates of each other,
+// since the same bytes can be counted differently.
+func (d *Deduper) FirstSeen(job FileJob) bool {
+	if d == nil {
+		return true
+	}
+
+	content := job.Content
+	if d.mode == DedupeNormalized {
+		content = normalize(content)
+	}
+
+	hash := sha256.New()
+	if job.Extractor != nil {
+		hash.Write([]byte(job.Extractor.Name()))
+	}
+	hash.Write([]byte{0})
+	hash.Write(content)
+	var sum [sha256.Size]byte
+	hash.Sum(sum[:0])
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen[sum] {
+		return false
+	}
+	d.seen[sum] = true
+	return true
+}
+
+// normalize converts CRLF line endings to LF, and drops trailing whitespace
+// on each line and blank lines at the end.
+func normalize(content []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(content))
+	for line := range bytes.Lines(content) {
+		out.Write(bytes.TrimRight(line, " \t\r\n"))
+		out.WriteByte('\n')
+	}
+	return bytes.TrimRight(out.Bytes(), "\n")
+}
@@ -2,20 +2,26 @@ package concurrent
 
 import (
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"unicode/utf8"
 
+	"github.com/ogdakke/symbolista/internal/extract"
 	"github.com/ogdakke/symbolista/internal/ignorer"
 	"github.com/ogdakke/symbolista/internal/logger"
 )
 
+// DiscoverFiles walks fsys, which holds the tree rooted at rootPath. Paths
+// are reported and matched joined to rootPath, so a directory on disk read
+// through os.DirFS behaves like any other source.
 func DiscoverFiles(
+	fsys fs.FS,
 	rootPath string,
 	matcher *ignorer.Matcher,
 	jobChan chan<- FileJob,
 	asciiOnly bool,
 	sequenceConfig SequenceConfig,
+	extractors *extract.Registry,
 	collector *ResultCollector,
 	progressCallback ProgressCallback,
 	errorCallback func(error),
@@ -24,7 +30,8 @@ func DiscoverFiles(
 
 	logger.Debug("Starting file discovery", "root_path", rootPath)
 
-	err := filepath.WalkDir(rootPath, func(path string, d os.DirEntry, err error) error {
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		path := filepath.Join(rootPath, filepath.FromSlash(name))
 		if err != nil {
 			if errorCallback != nil {
 				errorCallback(err)
@@ -39,9 +46,11 @@ func DiscoverFiles(
 				}
 			}
 
-			if path != rootPath && matcher != nil && matcher.ShouldIgnore(path) {
-				logger.Debug("Skipping directory (gitignore)", "path", path)
-				return filepath.SkipDir
+			if name != "." && matcher != nil {
+				if decision := matcher.Decide(path, true); decision.Ignored {
+					logger.Debug("Skipping directory", "path", path, "reason", decision)
+					return filepath.SkipDir
+				}
 			}
 			logger.Trace("Entering directory", "path", path)
 			return nil
@@ -54,19 +63,21 @@ func DiscoverFiles(
 			progressCallback(filesFound, filesFound-filesIgnored)
 		}
 
-		if d.Type()&os.ModeType != 0 {
-			logger.Debug("Skipping special file", "path", path, "mode", d.Type().String())
+		if decision := ignorer.DecideMode(d.Type()); decision.Ignored {
+			logger.Debug("Skipping file", "path", path, "reason", decision)
 			collector.IncrementIgnored()
 			return nil
 		}
 
-		if matcher != nil && matcher.ShouldIgnore(path) {
-			logger.Debug("Skipping file (gitignore)", "path", path)
-			collector.IncrementIgnored()
-			return nil
+		if matcher != nil {
+			if decision := matcher.Decide(path, false); decision.Ignored {
+				logger.Debug("Skipping file", "path", path, "reason", decision)
+				collector.IncrementIgnored()
+				return nil
+			}
 		}
 
-		file, err := os.Open(path)
+		file, err := fsys.Open(name)
 		if err != nil {
 			logger.Debug("Cannot read file", "path", path, "error", err)
 			collector.IncrementIgnored()
@@ -74,43 +85,193 @@ func DiscoverFiles(
 		}
 		defer file.Close()
 
-		content, err := io.ReadAll(file)
+		queueFile(file, path, matcher, jobChan, asciiOnly, sequenceConfig, extractors.For(path), collector)
+		return nil
+	})
+
+	if err != nil && errorCallback != nil {
+		errorCallback(err)
+	}
+
+	logger.Debug("File discovery completed")
+}
+
+// DiscoverFileList queues an explicit list of files instead of walking a
+// directory tree. Directories are never entered, so no gitignore files are
+// loaded along the way.
+func DiscoverFileList(
+	paths []string,
+	matcher *ignorer.Matcher,
+	jobChan chan<- FileJob,
+	asciiOnly bool,
+	sequenceConfig SequenceConfig,
+	extractors *extract.Registry,
+	collector *ResultCollector,
+	progressCallback ProgressCallback,
+	errorCallback func(error),
+) {
+	defer close(jobChan)
+
+	logger.Debug("Starting file list discovery", "files", len(paths))
+
+	for _, path := range paths {
+		collector.IncrementFound()
+
+		if progressCallback != nil {
+			_, _, _, _, _, filesFound, filesIgnored, _ := collector.GetResults()
+			progressCallback(filesFound, filesFound-filesIgnored)
+		}
+
+		info, err := os.Lstat(path)
 		if err != nil {
-			logger.Debug("Cannot read file content", "path", path, "error", err)
+			logger.Debug("Cannot stat file", "path", path, "error", err)
 			collector.IncrementIgnored()
-			return nil
+			continue
 		}
 
-		if !utf8.Valid(content) {
-			logger.Debug("Skipping non-UTF8 file", "path", path)
+		if decision := ignorer.DecideMode(info.Mode()); decision.Ignored {
+			logger.Debug("Skipping file", "path", path, "reason", decision)
 			collector.IncrementIgnored()
-			return nil
+			continue
+		}
+
+		if matcher != nil {
+			if decision := matcher.Decide(path, false); decision.Ignored {
+				logger.Debug("Skipping file", "path", path, "reason", decision)
+				collector.IncrementIgnored()
+				continue
+			}
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			logger.Debug("Cannot read file", "path", path, "error", err)
+			collector.IncrementIgnored()
+			continue
+		}
+		queueFile(file, path, matcher, jobChan, asciiOnly, sequenceConfig, extractors.For(path), collector)
+		file.Close()
+	}
+
+	logger.Debug("File list discovery completed")
+}
+
+// Content is text to count under a file path, for input that does not come
+// from reading whole files, such as the lines added to a file in a diff.
+type Content struct {
+	Path    string
+	Content []byte
+}
+
+// DiscoverContents queues content that was already read. Ignore rules still
+// apply to the paths, and the size limit applies to the content.
+func DiscoverContents(
+	contents []Content,
+	matcher *ignorer.Matcher,
+	jobChan chan<- FileJob,
+	asciiOnly bool,
+	sequenceConfig SequenceConfig,
+	collector *ResultCollector,
+	progressCallback ProgressCallback,
+	errorCallback func(error),
+) {
+	defer close(jobChan)
+
+	logger.Debug("Starting content discovery", "files", len(contents))
+
+	for _, content := range contents {
+		collector.IncrementFound()
+
+		if progressCallback != nil {
+			_, _, _, _, _, filesFound, filesIgnored, _ := collector.GetResults()
+			progressCallback(filesFound, filesFound-filesIgnored)
 		}
 
-		logger.Trace("Discovered file", "path", path, "size", len(content))
+		if matcher != nil {
+			if decision := matcher.Decide(content.Path, false); decision.Ignored {
+				logger.Debug("Skipping file", "path", content.Path, "reason", decision)
+				collector.IncrementIgnored()
+				continue
+			}
+		}
 
-		job := FileJob{
-			Path:           path,
-			Content:        content,
-			AsciiOnly:      asciiOnly,
-			SequenceConfig: sequenceConfig,
+		if decision := matcher.DecideSize(int64(len(content.Content))); decision.Ignored {
+			logger.Debug("Skipping file", "path", content.Path, "reason", decision)
+			collector.IncrementIgnored()
+			continue
 		}
 
-		select {
-		case jobChan <- job:
+		queueContent(content.Path, content.Content, jobChan, asciiOnly, sequenceConfig, nil, collector)
+	}
 
-		default:
+	logger.Debug("Content discovery completed")
+}
 
-			logger.Debug("Job channel full, this may indicate a bottleneck", "path", path)
-			jobChan <- job
+func queueFile(
+	file fs.File,
+	path string,
+	matcher *ignorer.Matcher,
+	jobChan chan<- FileJob,
+	asciiOnly bool,
+	sequenceConfig SequenceConfig,
+	extractor extract.Extractor,
+	collector *ResultCollector,
+) {
+	if matcher.SizeLimit() > 0 {
+		info, err := file.Stat()
+		if err != nil {
+			logger.Debug("Cannot stat file", "path", path, "error", err)
+			collector.IncrementIgnored()
+			return
 		}
+		if decision := matcher.DecideSize(info.Size()); decision.Ignored {
+			logger.Debug("Skipping file", "path", path, "reason", decision)
+			collector.IncrementIgnored()
+			return
+		}
+	}
 
-		return nil
-	})
+	content, err := io.ReadAll(file)
+	if err != nil {
+		logger.Debug("Cannot read file content", "path", path, "error", err)
+		collector.IncrementIgnored()
+		return
+	}
 
-	if err != nil && errorCallback != nil {
-		errorCallback(err)
+	queueContent(path, content, jobChan, asciiOnly, sequenceConfig, extractor, collector)
+}
+
+func queueContent(
+	path string,
+	content []byte,
+	jobChan chan<- FileJob,
+	asciiOnly bool,
+	sequenceConfig SequenceConfig,
+	extractor extract.Extractor,
+	collector *ResultCollector,
+) {
+	if decision := ignorer.DecideContent(content); decision.Ignored {
+		logger.Debug("Skipping file", "path", path, "reason", decision)
+		collector.IncrementIgnored()
+		return
 	}
 
-	logger.Debug("File discovery completed")
+	logger.Trace("Discovered file", "path", path, "size", len(content))
+
+	job := FileJob{
+		Path:           path,
+		Content:        content,
+		AsciiOnly:      asciiOnly,
+		SequenceConfig: sequenceConfig,
+		Extractor:      extractor,
+	}
+
+	select {
+	case jobChan <- job:
+
+	default:
+
+		logger.Debug("Job channel full, this may indicate a bottleneck", "path", path)
+		jobChan <- job
+	}
 }
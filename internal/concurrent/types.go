@@ -4,6 +4,8 @@ import (
 	"maps"
 	"sync"
 	"time"
+
+	"github.com/ogdakke/symbolista/internal/extract"
 )
 
 type FileJob struct {
@@ -11,6 +13,8 @@ type FileJob struct {
 	Content        []byte
 	AsciiOnly      bool
 	SequenceConfig SequenceConfig
+	// Extractor, if set, picks the text to count out of Content
+	Extractor extract.Extractor
 }
 
 type SequenceConfig struct {
@@ -22,12 +26,22 @@ type SequenceConfig struct {
 
 type ProgressCallback func(filesFound, filesProcessed int)
 
+// FileCallback is called with the result of each counted file as it leaves
+// the worker pool. It must not modify the result, which is added to the
+// totals after it returns.
+type FileCallback func(result CharCountResult)
+
 type CharCountResult struct {
+	Path         string
 	CharMap      map[rune]int
 	SequenceMap2 map[uint16]uint32
 	SequenceMap3 map[uint32]uint32
 	FileCount    int
 	CharCount    int
+	// Duplicate is set when the file was skipped because its content was
+	// already counted; DuplicateBytes is then its size
+	Duplicate      bool
+	DuplicateBytes int
 }
 
 type Worker struct {
@@ -41,6 +55,7 @@ type WorkerPool struct {
 	done        chan bool
 	wg          sync.WaitGroup
 	workers     []*Worker
+	deduper     *Deduper
 }
 
 type ResultTiming struct {
@@ -55,6 +70,8 @@ type ResultCollector struct {
 	totalChars        int
 	filesFound        int
 	filesIgnored      int
+	duplicateFiles    int
+	duplicateBytes    int64
 	mu                sync.RWMutex
 	timing            ResultTiming
 }
@@ -91,6 +108,10 @@ func (rc *ResultCollector) AddResult(result CharCountResult) {
 
 	rc.totalFiles += result.FileCount
 	rc.totalChars += result.CharCount
+	if result.Duplicate {
+		rc.duplicateFiles++
+		rc.duplicateBytes += int64(result.DuplicateBytes)
+	}
 
 	rc.timing.Values["AddResult"] = rc.timing.Values["AddResult"] + time.Since(startAdding)
 
@@ -138,3 +159,11 @@ func (rc *ResultCollector) GetResults() (
 		rc.filesIgnored,
 		rc.timing
 }
+
+// GetDuplicates returns the number of files skipped as duplicates and their
+// total size in bytes.
+func (rc *ResultCollector) GetDuplicates() (int, int64) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.duplicateFiles, rc.duplicateBytes
+}
@@ -40,6 +40,12 @@ func (wp *WorkerPool) Start() {
 	}()
 }
 
+// SetDeduper makes the workers skip files whose content was already counted.
+// It must be called before Start.
+func (wp *WorkerPool) SetDeduper(deduper *Deduper) {
+	wp.deduper = deduper
+}
+
 func (wp *WorkerPool) AddJob(job FileJob) {
 	wp.jobs <- job
 }
@@ -81,7 +87,22 @@ func (wp *WorkerPool) processFile(job FileJob, workerID int) CharCountResult {
 
 	logger.Trace("Processing file", "path", job.Path, "worker_id", workerID, "size", len(job.Content))
 
-	content := strings.ToLower(string(job.Content))
+	if !wp.deduper.FirstSeen(job) {
+		logger.Trace("Skipping duplicate file", "path", job.Path)
+		return CharCountResult{Path: job.Path, Duplicate: true, DuplicateBytes: len(job.Content)}
+	}
+
+	raw := job.Content
+	if job.Extractor != nil {
+		extracted, err := job.Extractor.Extract(raw)
+		if err != nil {
+			logger.Debug("Cannot extract content, counting the whole file", "path", job.Path, "extractor", job.Extractor.Name(), "error", err)
+		} else {
+			raw = extracted
+		}
+	}
+
+	content := strings.ToLower(string(raw))
 	n := len(content)
 
 	sequenceMap2 := make(map[uint16]uint32, n)
@@ -115,6 +136,7 @@ func (wp *WorkerPool) processFile(job FileJob, workerID int) CharCountResult {
 	worker.fileCount++
 
 	return CharCountResult{
+		Path:         job.Path,
 		CharMap:      charMap,
 		SequenceMap2: sequenceMap2,
 		SequenceMap3: sequenceMap3,
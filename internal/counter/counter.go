@@ -2,36 +2,154 @@ package counter
 
 import (
 	"fmt"
+	"io/fs"
+	"math"
 	"os"
+	"runtime"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/ogdakke/symbolista/internal/archive"
 	"github.com/ogdakke/symbolista/internal/concurrent"
 	"github.com/ogdakke/symbolista/internal/domain"
+	"github.com/ogdakke/symbolista/internal/extract"
+	"github.com/ogdakke/symbolista/internal/git"
+	"github.com/ogdakke/symbolista/internal/history"
 	"github.com/ogdakke/symbolista/internal/ignorer"
 	"github.com/ogdakke/symbolista/internal/logger"
 	"github.com/ogdakke/symbolista/internal/output"
 	"github.com/ogdakke/symbolista/internal/traversal"
 )
 
+// Options holds the settings that control how a directory is analyzed.
+type Options struct {
+	WorkerCount    int
+	AsciiOnly      bool
+	TopNSeq        int
+	SequenceConfig concurrent.SequenceConfig
+	Ignore         ignorer.Options
+	// GitTracked counts exactly the files listed in the git index instead of
+	// walking the directory and applying gitignore rules.
+	GitTracked bool
+	// GitDiff counts only the lines added by the selected changes, instead of
+	// whole files.
+	GitDiff git.DiffSpec
+	// Authors restricts counting to the lines git blame attributes to these
+	// email addresses, in the files tracked by git.
+	Authors []string
+	// Rev analyzes the files of a git revision, read from the object
+	// database, with the ignore files of that revision.
+	Rev string
+	// Files counts exactly these files, such as a list from another tool,
+	// instead of walking the directory. nil walks the directory.
+	Files []string
+	// Stdin counts this text, read from stdin, instead of any files. nil
+	// when not reading stdin.
+	Stdin []byte
+	// Extractors pick the text to count out of structured files such as
+	// notebooks when whole files are read; nil counts files as they are
+	Extractors *extract.Registry
+	// Dedupe counts each distinct file content once when whole files are
+	// read; the duplicates are reported in the result
+	Dedupe concurrent.DedupeMode
+	// OnFile, if set, is called with the counts of each file as soon as it
+	// is counted, before the result is complete
+	OnFile func(domain.FileCounts)
+	// History configures how shell history roots are read
+	History history.Options
+	// FS is walked instead of the directory on disk; paths are reported
+	// below the directory. A directory that is a zip or tar archive is
+	// opened as an FS automatically.
+	FS fs.FS
+}
+
+// NewSequenceConfig returns the default sequence settings.
+func NewSequenceConfig(enabled bool) concurrent.SequenceConfig {
+	return concurrent.SequenceConfig{
+		Enabled:   enabled,
+		MinLength: 2,
+		MaxLength: 3,
+		Threshold: 2,
+	}
+}
+
 func AnalyzeSymbols(
 	directory string,
-	workerCount int,
-	includeDotfiles bool,
-	asciiOnly bool,
-	sequenceConfig concurrent.SequenceConfig,
+	opts Options,
 	progressCallback func(filesFound, filesProcessed int),
-	topNSeq int,
 ) (domain.AnalysisResult, error) {
 	startTime := time.Now()
 
-	logger.Info("Initializing gitignore matcher", "directory", directory, "includeDotfiles", includeDotfiles)
-	matcher, err := ignorer.NewTimingMatcher(directory, includeDotfiles)
+	counts, err := countDirectory(directory, opts, progressCallback)
+	if err != nil {
+		return domain.AnalysisResult{}, err
+	}
+
+	return summarize([]weightedCounts{{rawCounts: counts, weight: 1}}, opts, startTime), nil
+}
+
+// rawCounts are the counts of one directory before they are sorted into a
+// result.
+type rawCounts struct {
+	chars             map[rune]int
+	sequences         map[string]int
+	filesFound        int
+	filesIgnored      int
+	totalChars        int
+	duplicateFiles    int
+	duplicateBytes    int64
+	authors           *domain.AuthorStats
+	gitignoreDuration time.Duration
+	traversalDuration time.Duration
+}
+
+type weightedCounts struct {
+	rawCounts
+	weight float64
+}
+
+func countDirectory(
+	directory string,
+	opts Options,
+	progressCallback func(filesFound, filesProcessed int),
+) (rawCounts, error) {
+	sequenceConfig := opts.SequenceConfig
+
+	ignoreOptions := opts.Ignore
+	ignoreOptions.DisableGitignore = ignoreOptions.DisableGitignore || opts.GitTracked || !opts.GitDiff.IsZero() || len(opts.Authors) > 0 || opts.Files != nil || opts.Stdin != nil
+	if opts.Stdin != nil {
+		ignoreOptions.LinguistAttributes = false
+		ignoreOptions.VendorDirs = nil
+	}
+
+	fsys := opts.FS
+	if opts.Rev != "" {
+		tree, err := openRevision(directory, opts.Rev)
+		if err != nil {
+			return rawCounts{}, fmt.Errorf("could not read revision %s: %w", opts.Rev, err)
+		}
+		defer tree.Close()
+		logger.Info("Reading revision", "rev", tree.Rev, "files", len(tree.Files))
+		fsys = tree
+	} else if fsys == nil && archive.IsArchive(directory) {
+		if info, err := os.Stat(directory); err == nil && info.Mode().IsRegular() {
+			opened, err := archive.Open(directory)
+			if err != nil {
+				return rawCounts{}, fmt.Errorf("could not open archive: %w", err)
+			}
+			defer opened.Close()
+			fsys = opened
+		}
+	}
+	ignoreOptions.FS = fsys
+
+	logger.Info("Initializing gitignore matcher", "directory", directory, "includeDotfiles", ignoreOptions.IncludeDotfiles, "gitTracked", opts.GitTracked, "presets", ignoreOptions.Presets)
+	matcher, err := ignorer.NewTimingMatcher(directory, ignoreOptions)
 
 	if err != nil {
-		logger.Error("Could not load gitignore", "error", err)
-		return domain.AnalysisResult{}, fmt.Errorf("could not load gitignore: %w", err)
+		logger.Error("Could not load ignore rules", "error", err)
+		return rawCounts{}, fmt.Errorf("could not load ignore rules: %w", err)
 	} else {
 		logger.Debug("Gitignore matcher created successfully", "initial_duration", matcher.GetLoadTime())
 	}
@@ -39,22 +157,65 @@ func AnalyzeSymbols(
 	logger.Info("Starting concurrent file traversal and character counting")
 	traversalStart := time.Now()
 
-	result, err := traversal.WalkDirectoryConcurrent(directory, matcher.Matcher, workerCount, asciiOnly, sequenceConfig, progressCallback)
+	var result traversal.ConcurrentResult
+	var authorStats *domain.AuthorStats
+	if opts.Stdin != nil {
+		// Ignore rules are about files, so none apply to piped text
+		stdin := []concurrent.Content{{Path: "<stdin>", Content: opts.Stdin}}
+		result, err = traversal.ProcessContentsConcurrent(stdin, nil, opts.WorkerCount, opts.AsciiOnly, sequenceConfig, progressCallback, opts.fileCallback())
+	} else if opts.Files != nil {
+		result, err = traversal.ProcessFilesConcurrent(opts.Files, matcher.Matcher, opts.WorkerCount, opts.AsciiOnly, sequenceConfig, opts.Extractors, concurrent.NewDeduper(opts.Dedupe), progressCallback, opts.fileCallback())
+	} else if len(opts.Authors) > 0 {
+		result, authorStats, err = processAuthoredLines(directory, matcher.Matcher, opts, progressCallback)
+	} else if opts.GitTracked {
+		result, err = processTrackedFiles(directory, matcher.Matcher, opts, progressCallback)
+	} else if !opts.GitDiff.IsZero() {
+		result, err = processAddedLines(directory, matcher.Matcher, opts, progressCallback)
+	} else if fsys != nil {
+		result, err = traversal.WalkFSConcurrent(fsys, directory, matcher.Matcher, opts.WorkerCount, opts.AsciiOnly, sequenceConfig, opts.Extractors, concurrent.NewDeduper(opts.Dedupe), progressCallback, opts.fileCallback())
+	} else {
+		result, err = traversal.WalkDirectoryConcurrent(directory, matcher.Matcher, opts.WorkerCount, opts.AsciiOnly, sequenceConfig, opts.Extractors, concurrent.NewDeduper(opts.Dedupe), progressCallback, opts.fileCallback())
+	}
 	traversalDuration := time.Since(traversalStart)
 
 	if err != nil {
 		logger.Error("Error during file processing", "error", err, "duration", traversalDuration)
-		return domain.AnalysisResult{}, fmt.Errorf("error processing files: %w", err)
+		return rawCounts{}, fmt.Errorf("error processing files: %w", err)
 	}
 
 	gitignoreDuration := matcher.GetTotalTime()
 
-	charMap := result.CharMap
-	sequenceMap2 := result.SequenceMap2
-	sequenceMap3 := result.SequenceMap3
+	logger.Info("File processing completed",
+		"files_found", result.FilesFound,
+		"files_processed", result.FileCount,
+		"files_ignored", result.FilesIgnored,
+		"total_characters", result.TotalChars,
+		"unique_characters", len(result.CharMap),
+		"traversal_duration", traversalDuration)
+
+	counts := newRawCounts(result)
+	counts.authors = authorStats
+	counts.gitignoreDuration = gitignoreDuration
+	counts.traversalDuration = traversalDuration
+	return counts, nil
+}
+
+func newRawCounts(result traversal.ConcurrentResult) rawCounts {
+	return rawCounts{
+		chars:          result.CharMap,
+		sequences:      sequenceStrings(result.SequenceMap2, result.SequenceMap3),
+		filesFound:     result.FilesFound,
+		filesIgnored:   result.FilesIgnored,
+		totalChars:     result.TotalChars,
+		duplicateFiles: result.DuplicateFiles,
+		duplicateBytes: result.DuplicateBytes,
+	}
+}
 
-	// Convert uint16/uint32 keys back to strings and combine
-	sequenceMap := make(map[string]int)
+// sequenceStrings converts the uint16/uint32 keys of the workers back to
+// strings and combines them.
+func sequenceStrings(sequenceMap2 map[uint16]uint32, sequenceMap3 map[uint32]uint32) map[string]int {
+	sequenceMap := make(map[string]int, len(sequenceMap2)+len(sequenceMap3))
 	for k2, count := range sequenceMap2 {
 		seq := string([]byte{byte(k2 >> 8), byte(k2)})
 		sequenceMap[seq] = int(count)
@@ -63,46 +224,99 @@ func AnalyzeSymbols(
 		seq := string([]byte{byte(k3 >> 16), byte(k3 >> 8), byte(k3)})
 		sequenceMap[seq] = int(count)
 	}
-	totalChars := result.TotalChars
-	processedFiles := result.FileCount
-	filesFound := result.FilesFound
-	filesIgnored := result.FilesIgnored
+	return sequenceMap
+}
 
-	logger.Info("File processing completed",
-		"files_found", filesFound,
-		"files_processed", processedFiles,
-		"files_ignored", filesIgnored,
-		"total_characters", totalChars,
-		"unique_characters", len(charMap),
-		"traversal_duration", traversalDuration)
+// fileCallback passes each file's counts to OnFile.
+func (o Options) fileCallback() concurrent.FileCallback {
+	if o.OnFile == nil {
+		return nil
+	}
+	return func(result concurrent.CharCountResult) {
+		o.OnFile(domain.FileCounts{
+			Path:       result.Path,
+			Chars:      result.CharMap,
+			Sequences:  sequenceStrings(result.SequenceMap2, result.SequenceMap3),
+			TotalChars: result.CharCount,
+		})
+	}
+}
 
-	sortingStart := time.Now()
+func addWeighted(into map[rune]float64, chars map[rune]int, weight float64) {
+	for char, count := range chars {
+		into[char] += float64(count) * weight
+	}
+}
 
-	// Process character counts
+func charCounts(charMap map[rune]float64, totalChars float64) domain.CharCounts {
 	var counts domain.CharCounts
 	for char, count := range charMap {
-		percentage := float64(count) / float64(totalChars) * 100
+		percentage := count / totalChars * 100
 		counts = append(counts, domain.CharCount{
 			Char:       strings.ToLower(string(char)),
-			Count:      count,
+			Count:      int(math.Round(count)),
 			Percentage: percentage,
 		})
 	}
 	sort.Sort(counts)
+	return counts
+}
+
+// summarize sorts the counts of one or more directories into a result. Each
+// directory's counts are multiplied by its weight before they are combined,
+// and percentages are computed from the weighted counts.
+func summarize(parts []weightedCounts, opts Options, startTime time.Time) domain.AnalysisResult {
+	sortingStart := time.Now()
+
+	charMap := make(map[rune]float64)
+	sequenceMap := make(map[string]float64)
+	var totalChars float64
+	var filesFound, filesIgnored int
+	var gitignoreDuration, traversalDuration time.Duration
+	var authorStats *domain.AuthorStats
+	var duplicateStats *domain.DuplicateStats
+	if opts.Dedupe != concurrent.DedupeOff {
+		duplicateStats = &domain.DuplicateStats{Mode: string(opts.Dedupe)}
+	}
+	for _, part := range parts {
+		addWeighted(charMap, part.chars, part.weight)
+		for sequence, count := range part.sequences {
+			sequenceMap[sequence] += float64(count) * part.weight
+		}
+		totalChars += float64(part.totalChars) * part.weight
+		filesFound += part.filesFound
+		filesIgnored += part.filesIgnored
+		gitignoreDuration += part.gitignoreDuration
+		traversalDuration += part.traversalDuration
+		if duplicateStats != nil {
+			duplicateStats.Files += part.duplicateFiles
+			duplicateStats.BytesSaved += part.duplicateBytes
+		}
+
+		if part.authors != nil {
+			if authorStats == nil {
+				authorStats = &domain.AuthorStats{Authors: part.authors.Authors}
+			}
+			authorStats.AttributedLines += part.authors.AttributedLines
+			authorStats.SkippedLines += part.authors.SkippedLines
+		}
+	}
+
+	counts := charCounts(charMap, totalChars)
 
 	// Process sequence counts
 	var sequenceCounts domain.SequenceCounts
-	totalSequences := 0
+	var totalSequences float64
 	for _, count := range sequenceMap {
 		totalSequences += count
 	}
 
 	for sequence, count := range sequenceMap {
-		if count >= sequenceConfig.Threshold {
-			percentage := float64(count) / float64(totalSequences) * 100
+		if count >= float64(opts.SequenceConfig.Threshold) {
+			percentage := count / totalSequences * 100
 			sequenceCounts = append(sequenceCounts, domain.SequenceCount{
 				Sequence:   sequence,
-				Count:      count,
+				Count:      int(math.Round(count)),
 				Percentage: percentage,
 			})
 		}
@@ -110,8 +324,8 @@ func AnalyzeSymbols(
 	sort.Sort(sequenceCounts)
 
 	// Limit sequences to top N if specified
-	if topNSeq > 0 && len(sequenceCounts) > topNSeq {
-		sequenceCounts = sequenceCounts[:topNSeq]
+	if opts.TopNSeq > 0 && len(sequenceCounts) > opts.TopNSeq {
+		sequenceCounts = sequenceCounts[:opts.TopNSeq]
 	}
 
 	sortingDuration := time.Since(sortingStart)
@@ -138,49 +352,108 @@ func AnalyzeSymbols(
 		SequenceCounts:  sequenceCounts,
 		FilesFound:      filesFound,
 		FilesIgnored:    filesIgnored,
-		TotalChars:      totalChars,
+		TotalChars:      int(math.Round(totalChars)),
 		UniqueChars:     len(charMap),
 		UniqueSequences: len(sequenceMap),
 		Timing:          timing,
-	}, nil
+		Authors:         authorStats,
+		Duplicates:      duplicateStats,
+		Config:          opts.runConfig(),
+	}
 }
 
-func CountSymbolsConcurrent(
-	outputter *output.Outputter,
-	directory, format string,
-	showPercentages bool,
-	workerCount int,
-	includeDotfiles bool,
-	asciiOnly bool,
-	includeMetadata bool,
-	topNSeq int,
-	countSequences bool,
-) {
+// runConfig records the options in the result.
+func (o Options) runConfig() *domain.RunConfig {
+	workers := o.WorkerCount
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
 
-	var progressFunc func(int, int)
+	var gitDiff string
+	if !o.GitDiff.IsZero() {
+		gitDiff = o.GitDiff.String()
+	}
 
-	progressFunc = func(filesFound, filesProcessed int) {
-		fmt.Fprintf(os.Stderr, "\rFiles found: %d, Processed: %d", filesFound, filesProcessed)
+	return &domain.RunConfig{
+		AsciiOnly:       o.AsciiOnly,
+		IncludeDotfiles: o.Ignore.IncludeDotfiles,
+		Workers:         workers,
+		TopNSequences:   o.TopNSeq,
+		Sequences: domain.SequenceConfig{
+			Enabled:   o.SequenceConfig.Enabled,
+			MinLength: o.SequenceConfig.MinLength,
+			MaxLength: o.SequenceConfig.MaxLength,
+			Threshold: o.SequenceConfig.Threshold,
+		},
+		Extensions:        o.Ignore.Extensions,
+		ExcludeExtensions: o.Ignore.ExcludeExtensions,
+		Presets:           o.Ignore.Presets,
+		MaxFileSize:       o.Ignore.MaxFileSize,
+		VendorDirs:        o.Ignore.VendorDirs,
+		Linguist:          o.Ignore.LinguistAttributes,
+		GitTracked:        o.GitTracked,
+		GitDiff:           gitDiff,
+		Authors:           o.Authors,
+		Rev:               o.Rev,
+		Dedupe:            string(o.Dedupe),
 	}
+}
 
-	sequenceConfig := concurrent.SequenceConfig{
-		Enabled:   countSequences,
-		MinLength: 2,
-		MaxLength: 3,
-		Threshold: 2,
+// CountSymbolsConcurrent analyzes the directory and writes the result to the
+// targets. It returns analysis errors, such as an invalid revision range, and
+// errors writing the targets.
+func CountSymbolsConcurrent(
+	outputter *output.Outputter,
+	directory string,
+	targets []output.Target,
+	showPercentages bool,
+	includeMetadata bool,
+	opts Options,
+) error {
+	if err := startStreams(outputter, targets, &opts); err != nil {
+		return err
 	}
+	defer outputter.CloseStreams()
 
-	result, err := AnalyzeSymbols(directory, workerCount, includeDotfiles, asciiOnly, sequenceConfig, progressFunc, topNSeq)
+	result, err := AnalyzeSymbols(directory, opts, printProgress)
 
 	fmt.Fprintf(os.Stderr, "\n")
 
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		return
+		return err
 	}
 
+	return printResult(outputter, result, directory, targets, showPercentages, includeMetadata)
+}
+
+// startStreams opens the targets that write files while they are counted.
+func startStreams(outputter *output.Outputter, targets []output.Target, opts *Options) error {
+	if err := outputter.OpenStreams(targets); err != nil {
+		return err
+	}
+	if outputter.Streaming() {
+		opts.OnFile = outputter.OutputFile
+	}
+	return nil
+}
+
+func printProgress(filesFound, filesProcessed int) {
+	fmt.Fprintf(os.Stderr, "\rFiles found: %d, Processed: %d", filesFound, filesProcessed)
+}
+
+// printResult writes the result to its targets and a summary to stderr.
+func printResult(
+	outputter *output.Outputter,
+	result domain.AnalysisResult,
+	directory string,
+	targets []output.Target,
+	showPercentages bool,
+	includeMetadata bool,
+) error {
 	outputStart := time.Now()
-	outputter.Output(format, result, showPercentages, directory, includeMetadata)
+	if err := outputter.OutputTargets(targets, result, showPercentages, directory, includeMetadata); err != nil {
+		return err
+	}
 	outputDuration := time.Since(outputStart)
 
 	result.Timing.OutputDuration = outputDuration
@@ -189,6 +462,12 @@ func CountSymbolsConcurrent(
 	fmt.Fprintf(os.Stderr, "Files/directories ignored: %d\n", result.FilesIgnored)
 	fmt.Fprintf(os.Stderr, "Total characters: %d\n", result.TotalChars)
 	fmt.Fprintf(os.Stderr, "Unique characters: %d\n", result.UniqueChars)
+	if result.Authors != nil {
+		fmt.Fprintf(os.Stderr, "Lines attributed to %s: %d (skipped %d)\n", strings.Join(result.Authors.Authors, ", "), result.Authors.AttributedLines, result.Authors.SkippedLines)
+	}
+	if result.Duplicates != nil {
+		fmt.Fprintf(os.Stderr, "Duplicate files skipped: %d (%d bytes saved)\n", result.Duplicates.Files, result.Duplicates.BytesSaved)
+	}
 
 	if logger.GetVerbosity() > 0 {
 		fmt.Fprintf(os.Stderr, "\nTiming Breakdown:\n")
@@ -198,4 +477,114 @@ func CountSymbolsConcurrent(
 		fmt.Fprintf(os.Stderr, "  Output formatting: %s\n", result.Timing.OutputDuration)
 	}
 	fmt.Fprintf(os.Stderr, "Total time: %s\n", totalDuration)
+	return nil
+}
+
+func processTrackedFiles(
+	directory string,
+	matcher *ignorer.Matcher,
+	opts Options,
+	progressCallback func(filesFound, filesProcessed int),
+) (traversal.ConcurrentResult, error) {
+	repo, err := git.FindRepository(directory)
+	if err != nil {
+		return traversal.ConcurrentResult{}, err
+	}
+
+	paths, err := repo.TrackedFiles(directory)
+	if err != nil {
+		return traversal.ConcurrentResult{}, fmt.Errorf("could not read git index: %w", err)
+	}
+
+	return traversal.ProcessFilesConcurrent(paths, matcher, opts.WorkerCount, opts.AsciiOnly, opts.SequenceConfig, opts.Extractors, concurrent.NewDeduper(opts.Dedupe), progressCallback, opts.fileCallback())
+}
+
+func processAddedLines(
+	directory string,
+	matcher *ignorer.Matcher,
+	opts Options,
+	progressCallback func(filesFound, filesProcessed int),
+) (traversal.ConcurrentResult, error) {
+	repo, err := git.FindRepository(directory)
+	if err != nil {
+		return traversal.ConcurrentResult{}, err
+	}
+
+	files, err := repo.AddedLines(directory, opts.GitDiff)
+	if err != nil {
+		return traversal.ConcurrentResult{}, fmt.Errorf("could not read added lines: %w", err)
+	}
+
+	contents := make([]concurrent.Content, len(files))
+	addedLines := 0
+	for i, file := range files {
+		contents[i] = concurrent.Content{Path: file.Path, Content: file.Content}
+		addedLines += file.Lines
+	}
+	logger.Info("Counting added lines", "changes", opts.GitDiff.String(), "files", len(files), "lines", addedLines)
+
+	return traversal.ProcessContentsConcurrent(contents, matcher, opts.WorkerCount, opts.AsciiOnly, opts.SequenceConfig, progressCallback, opts.fileCallback())
+}
+
+func processAuthoredLines(
+	directory string,
+	matcher *ignorer.Matcher,
+	opts Options,
+	progressCallback func(filesFound, filesProcessed int),
+) (traversal.ConcurrentResult, *domain.AuthorStats, error) {
+	repo, err := git.FindRepository(directory)
+	if err != nil {
+		return traversal.ConcurrentResult{}, nil, err
+	}
+
+	paths, err := repo.TrackedFiles(directory)
+	if err != nil {
+		return traversal.ConcurrentResult{}, nil, fmt.Errorf("could not read git index: %w", err)
+	}
+
+	// Blame is slow, so only blame files the ignore rules let through
+	var blamePaths []string
+	ignored := 0
+	for _, path := range paths {
+		if decision := matcher.Decide(path, false); decision.Ignored {
+			logger.Debug("Skipping file", "path", path, "reason", decision)
+			ignored++
+			continue
+		}
+		blamePaths = append(blamePaths, path)
+	}
+
+	logger.Info("Blaming tracked files", "files", len(blamePaths), "authors", opts.Authors)
+	files, stats, err := repo.AuthoredLines(blamePaths, opts.Authors, opts.WorkerCount)
+	if err != nil {
+		return traversal.ConcurrentResult{}, nil, fmt.Errorf("could not blame files: %w", err)
+	}
+
+	contents := make([]concurrent.Content, len(files))
+	for i, file := range files {
+		contents[i] = concurrent.Content{Path: file.Path, Content: file.Content}
+	}
+
+	result, err := traversal.ProcessContentsConcurrent(contents, matcher, opts.WorkerCount, opts.AsciiOnly, opts.SequenceConfig, progressCallback, opts.fileCallback())
+	if err != nil {
+		return traversal.ConcurrentResult{}, nil, err
+	}
+	// Files without attributed lines are not counted, like ignored files
+	skippedFiles := ignored + len(blamePaths) - len(files)
+	result.FilesFound += skippedFiles
+	result.FilesIgnored += skippedFiles
+
+	return result, &domain.AuthorStats{
+		Authors:         opts.Authors,
+		AttributedLines: stats.AttributedLines,
+		SkippedLines:    stats.SkippedLines,
+	}, nil
+}
+
+func openRevision(directory, rev string) (*git.Tree, error) {
+	repo, err := git.FindRepository(directory)
+	if err != nil {
+		return nil, err
+	}
+	return repo.OpenTree(directory, rev)
 }
@@ -1,9 +1,16 @@
 package counter
 
 import (
+	"archive/zip"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
 
+	"github.com/ogdakke/symbolista/internal/concurrent"
 	"github.com/ogdakke/symbolista/internal/domain"
+	"github.com/ogdakke/symbolista/internal/extract"
 )
 
 func TestCharCountSorting(t *testing.T) {
@@ -33,3 +40,321 @@ func TestCharCountSorting(t *testing.T) {
 		t.Error("Swap method did not work correctly")
 	}
 }
+
+func TestAnalyzeRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	writeFile(".gitignore", "*.log\n")
+	writeFile("main.go", "abc")
+	writeFile("sub/.gitignore", "skip.go\n")
+	writeFile("sub/skip.go", "xyz")
+	writeFile("sub/keep.go", "de")
+	git("init", "-q")
+	git("add", "-f", ".")
+	git("commit", "-q", "-m", "initial")
+
+	// The working tree differs from the revision in content and ignore rules
+	writeFile(".gitignore", "")
+	writeFile("main.go", "changed content")
+	writeFile("untracked.go", "untracked")
+
+	result, err := AnalyzeSymbols(dir, Options{Rev: "HEAD", SequenceConfig: NewSequenceConfig(false)}, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeSymbols failed: %v", err)
+	}
+
+	if result.TotalChars != len("abc")+len("de") {
+		t.Errorf("Expected only main.go and sub/keep.go from the revision to be counted, got %d characters", result.TotalChars)
+	}
+	// .gitignore, sub/.gitignore, main.go, sub/skip.go and sub/keep.go
+	if result.FilesFound != 5 || result.FilesIgnored != 3 {
+		t.Errorf("Expected 5 files found and 3 ignored, got %d and %d", result.FilesFound, result.FilesIgnored)
+	}
+
+	if _, err := AnalyzeSymbols(dir, Options{Rev: "does-not-exist"}, nil); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+
+	// Tracked files below a dot-directory are skipped like in the walk
+	writeFile(".github/ci.yml", "~~~")
+	git("add", ".github")
+	tracked, err := AnalyzeSymbols(dir, Options{GitTracked: true, SequenceConfig: NewSequenceConfig(false)}, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeSymbols failed: %v", err)
+	}
+	for _, count := range tracked.CharCounts {
+		if count.Char == "~" {
+			t.Errorf("Expected the tracked .github/ci.yml to be ignored, got %+v", tracked.CharCounts)
+		}
+	}
+}
+
+func TestAnalyzeStdinAndFileList(t *testing.T) {
+	result, err := AnalyzeSymbols("-", Options{Stdin: []byte("aab"), SequenceConfig: NewSequenceConfig(false)}, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeSymbols failed: %v", err)
+	}
+	if result.TotalChars != 3 || result.FilesFound != 1 || result.CharCounts[0].Char != "a" || result.CharCounts[0].Count != 2 {
+		t.Errorf("Unexpected result for stdin: %+v", result)
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.go": "abc", "b.svg": "<svg/>", "c.go": "unlisted"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	files := []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.svg"), filepath.Join(dir, "missing.go")}
+	result, err = AnalyzeSymbols(".", Options{Files: files, SequenceConfig: NewSequenceConfig(false)}, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeSymbols failed: %v", err)
+	}
+	// b.svg is ignored by the default extension rules, missing.go does not exist
+	if result.TotalChars != 3 || result.FilesFound != 3 || result.FilesIgnored != 2 {
+		t.Errorf("Unexpected result for file list: %+v", result)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".github"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".github", "ci.yml"), []byte("on: push"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files = []string{filepath.Join(dir, "a.go"), filepath.Join(dir, ".github", "ci.yml")}
+	result, err = AnalyzeSymbols(dir, Options{Files: files, SequenceConfig: NewSequenceConfig(false)}, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeSymbols failed: %v", err)
+	}
+	if result.TotalChars != 3 || result.FilesIgnored != 1 {
+		t.Errorf("Expected the listed .github/ci.yml to be ignored, got %+v", result)
+	}
+}
+
+func TestAnalyzeFSAndArchive(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":     {Data: []byte("gen/\n")},
+		"main.go":        {Data: []byte("aab")},
+		"gen/schema.go":  {Data: []byte("ignored")},
+		"docs/readme.md": {Data: []byte("c")},
+	}
+
+	result, err := AnalyzeSymbols("virtual", Options{FS: fsys, SequenceConfig: NewSequenceConfig(false)}, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeSymbols failed: %v", err)
+	}
+	if result.TotalChars != 4 || result.FilesFound-result.FilesIgnored != 2 {
+		t.Errorf("Unexpected result for FS: %+v", result)
+	}
+
+	path := filepath.Join(t.TempDir(), "src.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(file)
+	for name, entry := range fsys {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(entry.Data)
+	}
+	writer.Close()
+	file.Close()
+
+	archived, err := AnalyzeSymbols(path, Options{SequenceConfig: NewSequenceConfig(false)}, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeSymbols failed: %v", err)
+	}
+	if archived.TotalChars != result.TotalChars || archived.FilesFound != result.FilesFound {
+		t.Errorf("Expected the archive to match the FS, got %+v", archived)
+	}
+}
+
+func TestAnalyzeRoots(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	files := map[string]string{
+		filepath.Join(first, "a.go"):            "aaaa",
+		filepath.Join(first, ".gitignore"):      "skip.go\n",
+		filepath.Join(first, "skip.go"):         "zzzz",
+		filepath.Join(second, "b.go"):           "bb",
+		filepath.Join(second, "nested/c.go"):    "ab",
+		filepath.Join(second, "nested/skip.go"): "zz",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	roots := []Root{{Path: first, Weight: 1}, {Path: second, Weight: 2}}
+	result, err := AnalyzeRoots(roots, Options{SequenceConfig: NewSequenceConfig(false)}, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeRoots failed: %v", err)
+	}
+
+	// The first root's .gitignore must not apply to the second root, so
+	// weighted: a = 4 + 1*2, b = 3*2, z = 2*2
+	counts := map[string]domain.CharCount{}
+	for _, count := range result.CharCounts {
+		counts[count.Char] = count
+	}
+	if counts["a"].Count != 6 || counts["b"].Count != 6 || counts["z"].Count != 4 || result.TotalChars != 16 {
+		t.Errorf("Unexpected weighted counts: %+v (total %d)", result.CharCounts, result.TotalChars)
+	}
+	if counts["a"].Percentage != 37.5 || counts["z"].Percentage != 25 {
+		t.Errorf("Expected percentages of the weighted total, got %+v", result.CharCounts)
+	}
+
+	if len(result.Roots) != 2 {
+		t.Fatalf("Expected 2 roots, got %d", len(result.Roots))
+	}
+	if result.Roots[0].TotalChars != 4 || result.Roots[0].Share != 25 || result.Roots[1].TotalChars != 6 || result.Roots[1].Share != 75 {
+		t.Errorf("Unexpected per-root results: %+v", result.Roots)
+	}
+
+	if _, err := AnalyzeRoots([]Root{{Path: first, Weight: 0}}, Options{}, nil); err == nil {
+		t.Error("Expected an error for a zero weight")
+	}
+}
+
+func TestAnalyzeHistory(t *testing.T) {
+	dir := t.TempDir()
+	historyPath := filepath.Join(dir, ".zsh_history")
+	if err := os.WriteFile(historyPath, []byte(": 1:0;ls\n: 2:0;ls\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	codeDir := filepath.Join(dir, "code")
+	if err := os.Mkdir(codeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(codeDir, "a.go"), []byte("aa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{SequenceConfig: NewSequenceConfig(false)}
+	result, err := AnalyzeRoots([]Root{{Path: historyPath, Weight: 1, History: true}}, opts, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeRoots failed: %v", err)
+	}
+	// "ls\nls" without the zsh timestamps
+	if result.TotalChars != 5 || result.Roots != nil {
+		t.Errorf("Unexpected result for history: %+v", result)
+	}
+
+	opts.History.Dedupe = true
+	roots := []Root{{Path: codeDir, Weight: 1}, {Path: historyPath, Weight: 1, History: true}}
+	result, err = AnalyzeRoots(roots, opts, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeRoots failed: %v", err)
+	}
+	if result.TotalChars != 4 || len(result.Roots) != 2 || result.Roots[1].TotalChars != 2 {
+		t.Errorf("Unexpected result for code and deduplicated history: %+v", result)
+	}
+}
+
+func TestAnalyzeExtractsNotebooks(t *testing.T) {
+	dir := t.TempDir()
+	notebook := `{"cells": [{"cell_type": "code", "outputs": [{"text": "zzzz"}], "source": ["ab\n", "c"]}], "nbformat": 4}`
+	files := map[string]string{
+		"analysis.ipynb": notebook,
+		"README.md":      "xx\n```\nyy\n```\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := Options{SequenceConfig: NewSequenceConfig(false), Extractors: extract.Default(extract.MarkdownCodeOnly)}
+	result, err := AnalyzeSymbols(dir, opts, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeSymbols failed: %v", err)
+	}
+
+	// "ab\nc" from the notebook and "yy\n" from the README
+	counts := map[string]int{}
+	for _, count := range result.CharCounts {
+		counts[count.Char] = count.Count
+	}
+	if result.TotalChars != 7 || counts["y"] != 2 || counts["z"] != 0 || counts["x"] != 0 {
+		t.Errorf("Unexpected counts: %+v", result.CharCounts)
+	}
+}
+
+func TestAnalyzeDedupe(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a/config.yml": "key: value\n",
+		"b/config.yml": "key: value\n",
+		"c/config.yml": "key: value  \r\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		mode       concurrent.DedupeMode
+		files      int
+		bytesSaved int64
+	}{
+		{concurrent.DedupeExact, 1, 11},
+		// Which copy is counted depends on the order the workers finish
+		{concurrent.DedupeNormalized, 2, -1},
+	}
+	for _, tt := range tests {
+		opts := Options{SequenceConfig: NewSequenceConfig(false), Dedupe: tt.mode}
+		result, err := AnalyzeSymbols(dir, opts, nil)
+		if err != nil {
+			t.Fatalf("AnalyzeSymbols failed: %v", err)
+		}
+		if result.Duplicates == nil || result.Duplicates.Files != tt.files || tt.bytesSaved >= 0 && result.Duplicates.BytesSaved != tt.bytesSaved {
+			t.Errorf("%s: duplicates = %+v, want %d files and %d bytes", tt.mode, result.Duplicates, tt.files, tt.bytesSaved)
+		}
+	}
+
+	result, err := AnalyzeSymbols(dir, Options{SequenceConfig: NewSequenceConfig(false)}, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeSymbols failed: %v", err)
+	}
+	if result.Duplicates != nil {
+		t.Errorf("duplicates should only be reported with dedupe, got %+v", result.Duplicates)
+	}
+}
@@ -0,0 +1,37 @@
+package counter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ogdakke/symbolista/internal/concurrent"
+	"github.com/ogdakke/symbolista/internal/history"
+	"github.com/ogdakke/symbolista/internal/logger"
+	"github.com/ogdakke/symbolista/internal/traversal"
+)
+
+// countHistory counts the commands of a shell history file, one per line.
+// Ignore rules are about files, so none apply to the commands.
+func countHistory(
+	path string,
+	opts Options,
+	progressCallback func(filesFound, filesProcessed int),
+) (rawCounts, error) {
+	commands, err := history.ReadFile(path, opts.History)
+	if err != nil {
+		return rawCounts{}, fmt.Errorf("could not read history: %w", err)
+	}
+	logger.Info("Counting shell history", "file", path, "commands", len(commands), "dedupe", opts.History.Dedupe)
+
+	traversalStart := time.Now()
+	content := []byte(strings.Join(commands, "\n"))
+	result, err := traversal.ProcessContentsConcurrent([]concurrent.Content{{Path: path, Content: content}}, nil, opts.WorkerCount, opts.AsciiOnly, opts.SequenceConfig, progressCallback, opts.fileCallback())
+	if err != nil {
+		return rawCounts{}, fmt.Errorf("error processing history: %w", err)
+	}
+
+	counts := newRawCounts(result)
+	counts.traversalDuration = time.Since(traversalStart)
+	return counts, nil
+}
@@ -0,0 +1,122 @@
+package counter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+	"github.com/ogdakke/symbolista/internal/logger"
+	"github.com/ogdakke/symbolista/internal/output"
+)
+
+// Root is one of several directories analyzed together. Its counts are
+// multiplied by Weight in the combined result.
+type Root struct {
+	Path   string
+	Weight float64
+	// History reads Path as a shell history file instead of a directory
+	History bool
+}
+
+func (r Root) String() string {
+	if r.Weight == 1 {
+		return r.Path
+	}
+	return fmt.Sprintf("%s:%g", r.Path, r.Weight)
+}
+
+// AnalyzeRoots analyzes each root on its own, with its own ignore files, and
+// combines the weighted counts. With more than one root, the per-root
+// results are in Roots.
+func AnalyzeRoots(
+	roots []Root,
+	opts Options,
+	progressCallback func(filesFound, filesProcessed int),
+) (domain.AnalysisResult, error) {
+	if len(roots) == 0 {
+		return domain.AnalysisResult{}, fmt.Errorf("no directories to analyze")
+	}
+
+	startTime := time.Now()
+
+	parts := make([]weightedCounts, len(roots))
+	var weightedTotal float64
+	for i, root := range roots {
+		if root.Weight <= 0 {
+			return domain.AnalysisResult{}, fmt.Errorf("weight of %s must be positive, got %g", root.Path, root.Weight)
+		}
+
+		logger.Info("Analyzing root", "path", root.Path, "weight", root.Weight, "history", root.History)
+		var counts rawCounts
+		var err error
+		if root.History {
+			counts, err = countHistory(root.Path, opts, progressCallback)
+		} else {
+			counts, err = countDirectory(root.Path, opts, progressCallback)
+		}
+		if err != nil {
+			return domain.AnalysisResult{}, fmt.Errorf("%s: %w", root.Path, err)
+		}
+		parts[i] = weightedCounts{rawCounts: counts, weight: root.Weight}
+		weightedTotal += float64(counts.totalChars) * root.Weight
+	}
+
+	result := summarize(parts, opts, startTime)
+	if len(roots) == 1 {
+		return result, nil
+	}
+
+	for i, root := range roots {
+		part := parts[i]
+		chars := make(map[rune]float64, len(part.chars))
+		addWeighted(chars, part.chars, 1)
+
+		var share float64
+		if weightedTotal > 0 {
+			share = float64(part.totalChars) * root.Weight / weightedTotal * 100
+		}
+		result.Roots = append(result.Roots, domain.RootResult{
+			Path:         root.Path,
+			Weight:       root.Weight,
+			FilesFound:   part.filesFound,
+			FilesIgnored: part.filesIgnored,
+			TotalChars:   part.totalChars,
+			Share:        share,
+			Characters:   charCounts(chars, float64(part.totalChars)),
+		})
+	}
+
+	return result, nil
+}
+
+// CountRootsConcurrent is CountSymbolsConcurrent for several weighted
+// directories.
+func CountRootsConcurrent(
+	outputter *output.Outputter,
+	roots []Root,
+	targets []output.Target,
+	showPercentages bool,
+	includeMetadata bool,
+	opts Options,
+) error {
+	if err := startStreams(outputter, targets, &opts); err != nil {
+		return err
+	}
+	defer outputter.CloseStreams()
+
+	result, err := AnalyzeRoots(roots, opts, printProgress)
+
+	fmt.Fprintf(os.Stderr, "\n")
+
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(roots))
+	for i, root := range roots {
+		names[i] = root.String()
+	}
+	return printResult(outputter, result, strings.Join(names, ", "), targets, showPercentages, includeMetadata)
+}
@@ -44,6 +44,45 @@ type TimingBreakdown struct {
 	OutputDuration    time.Duration `json:"output_duration"`
 }
 
+// AuthorStats describes how many lines git blame attributed to the authors
+// the analysis was restricted to.
+type AuthorStats struct {
+	Authors         []string `json:"authors"`
+	AttributedLines int      `json:"attributed_lines"`
+	SkippedLines    int      `json:"skipped_lines"`
+}
+
+// DuplicateStats describes the files that were skipped because a file with
+// the same content was already counted.
+type DuplicateStats struct {
+	Mode       string `json:"mode"`
+	Files      int    `json:"files"`
+	BytesSaved int64  `json:"bytes_saved"`
+}
+
+// RootResult is one directory's or history file's part of an analysis of
+// several weighted sources. Its counts are not weighted.
+type RootResult struct {
+	Path         string  `json:"path"`
+	Weight       float64 `json:"weight"`
+	FilesFound   int     `json:"files_found"`
+	FilesIgnored int     `json:"files_ignored"`
+	TotalChars   int     `json:"total_characters"`
+	// Share is the percentage of the weighted combined characters that came
+	// from this source
+	Share      float64    `json:"share"`
+	Characters CharCounts `json:"characters"`
+}
+
+// FileCounts are the counts of a single file, before they are combined with
+// the other files.
+type FileCounts struct {
+	Path       string
+	Chars      map[rune]int
+	Sequences  map[string]int
+	TotalChars int
+}
+
 type AnalysisResult struct {
 	CharCounts      CharCounts
 	SequenceCounts  SequenceCounts
@@ -53,9 +92,21 @@ type AnalysisResult struct {
 	UniqueChars     int
 	UniqueSequences int
 	Timing          TimingBreakdown
+	// Authors is set when counting was restricted to some authors' lines
+	Authors *AuthorStats
+	// Duplicates is set when files with duplicate content were skipped
+	Duplicates *DuplicateStats
+	// Roots is set when several directories were combined; the counts above
+	// are then weighted
+	Roots []RootResult
+	// Config records the options the analysis ran with
+	Config *RunConfig
 }
 
 type JSONMetadata struct {
+	ToolVersion     string          `json:"tool_version,omitempty"`
+	GeneratedAt     time.Time       `json:"generated_at"`
+	Config          *RunConfig      `json:"config,omitempty"`
 	Directory       string          `json:"directory"`
 	FilesFound      int             `json:"files_found"`
 	FilesProcessed  int             `json:"files_processed"`
@@ -63,16 +114,21 @@ type JSONMetadata struct {
 	TotalCharacters int             `json:"total_characters"`
 	UniqueChars     int             `json:"unique_characters"`
 	Timing          TimingBreakdown `json:"timing"`
+	Authors         *AuthorStats    `json:"authors,omitempty"`
+	Duplicates      *DuplicateStats `json:"duplicates,omitempty"`
 }
 
 type JSONResult struct {
 	Characters CharCounts     `json:"characters"`
 	Sequences  SequenceCounts `json:"sequences"`
+	Roots      []RootResult   `json:"roots,omitempty"`
 }
 
 type JSONOutput struct {
-	Result   JSONResult    `json:"result"`
-	Metadata *JSONMetadata `json:"metadata,omitempty"`
+	// SchemaVersion is the format of the file, see SchemaVersion
+	SchemaVersion int           `json:"schema_version"`
+	Result        JSONResult    `json:"result"`
+	Metadata      *JSONMetadata `json:"metadata,omitempty"`
 }
 
 func Filter[T any](ss []T, test func(T) bool) (ret []T) {
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is the version of the JSON output written by this build. It
+// is bumped when fields are removed or change meaning, and the JSON Schema in
+// schema/ is updated with it. Files from before schema_version existed are
+// version 1.
+const SchemaVersion = 2
+
+// RunConfig records the options an analysis ran with, so saved results can
+// be interpreted later.
+type RunConfig struct {
+	AsciiOnly         bool           `json:"ascii_only"`
+	IncludeDotfiles   bool           `json:"include_dotfiles"`
+	Workers           int            `json:"workers"`
+	TopNSequences     int            `json:"top_n_sequences"`
+	Sequences         SequenceConfig `json:"sequences"`
+	Extensions        []string       `json:"extensions,omitempty"`
+	ExcludeExtensions []string       `json:"exclude_extensions,omitempty"`
+	Presets           []string       `json:"presets,omitempty"`
+	MaxFileSize       int64          `json:"max_file_size,omitempty"`
+	VendorDirs        []string       `json:"vendor_dirs,omitempty"`
+	Linguist          bool           `json:"linguist"`
+	GitTracked        bool           `json:"git_tracked,omitempty"`
+	GitDiff           string         `json:"git_diff,omitempty"`
+	Authors           []string       `json:"authors,omitempty"`
+	Rev               string         `json:"rev,omitempty"`
+	Dedupe            string         `json:"dedupe,omitempty"`
+	// Filter is set when the written characters and sequences were narrowed
+	Filter *FilterConfig `json:"filter,omitempty"`
+}
+
+// FilterConfig records the filters, limits and order applied to the result.
+// Only and ExcludeWhitespace also apply to the ndjson file lines.
+type FilterConfig struct {
+	Only                string  `json:"only,omitempty"`
+	ExcludeWhitespace   bool    `json:"exclude_whitespace"`
+	RelativePercentages bool    `json:"relative_percentages"`
+	MinCount            int     `json:"min_count,omitempty"`
+	MinPercent          float64 `json:"min_percent,omitempty"`
+	TopNChars           int     `json:"top_n_chars,omitempty"`
+	Sort                string  `json:"sort,omitempty"`
+}
+
+type SequenceConfig struct {
+	Enabled   bool `json:"enabled"`
+	MinLength int  `json:"min_length"`
+	MaxLength int  `json:"max_length"`
+	Threshold int  `json:"threshold"`
+}
+
+// ParseJSONOutput reads a file written by the json format, migrating files
+// of older schema versions to the current one.
+func ParseJSONOutput(data []byte) (JSONOutput, error) {
+	var header struct {
+		SchemaVersion *int            `json:"schema_version"`
+		Result        json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return JSONOutput{}, err
+	}
+	if header.Result == nil {
+		return JSONOutput{}, fmt.Errorf("no result found, is this a symbolista JSON file?")
+	}
+
+	version := 1
+	if header.SchemaVersion != nil {
+		version = *header.SchemaVersion
+	}
+	if version < 1 || version > SchemaVersion {
+		return JSONOutput{}, fmt.Errorf("unsupported schema version %d, this version of symbolista reads versions 1 to %d", version, SchemaVersion)
+	}
+
+	var output JSONOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return JSONOutput{}, err
+	}
+
+	// Version 1 only lacks schema_version and the tool version, time and
+	// config in the metadata, so its fields read as they are
+	output.SchemaVersion = SchemaVersion
+	return output, nil
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONOutput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		chars int
+		valid bool
+	}{
+		{"version 1", `{"result":{"characters":[{"char":"a","count":1,"percentage":100}],"sequences":[]},"metadata":{"directory":"."}}`, 1, true},
+		{"version 2", `{"schema_version":2,"result":{"characters":[],"sequences":[]},"metadata":{"tool_version":"v0.2.0","generated_at":"2026-01-02T03:04:05Z","config":{"workers":4}}}`, 0, true},
+		{"future version", `{"schema_version":3,"result":{"characters":[],"sequences":[]}}`, 0, false},
+		{"zero version", `{"schema_version":0,"result":{"characters":[],"sequences":[]}}`, 0, false},
+		{"no result", `{"characters":[]}`, 0, false},
+		{"not JSON", `char,count`, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := ParseJSONOutput([]byte(tt.input))
+			if (err == nil) != tt.valid {
+				t.Fatalf("Expected valid=%v, got error %v", tt.valid, err)
+			}
+			if !tt.valid {
+				return
+			}
+			if output.SchemaVersion != SchemaVersion {
+				t.Errorf("Expected schema version %d, got %d", SchemaVersion, output.SchemaVersion)
+			}
+			if len(output.Result.Characters) != tt.chars {
+				t.Errorf("Expected %d characters, got %d", tt.chars, len(output.Result.Characters))
+			}
+		})
+	}
+}
+
+// TestSchemaDocument checks that the published schema has the current
+// version and describes every field the json format writes.
+func TestSchemaDocument(t *testing.T) {
+	data, err := os.ReadFile("../../schema/symbolista-output.schema.json")
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+
+	properties := schema["properties"].(map[string]any)
+	version := properties["schema_version"].(map[string]any)["const"].(float64)
+	if int(version) != SchemaVersion {
+		t.Errorf("Schema describes version %v, expected %d", version, SchemaVersion)
+	}
+
+	for _, name := range jsonFields(reflect.TypeOf(JSONOutput{})) {
+		if !strings.Contains(string(data), `"`+name+`"`) {
+			t.Errorf("Schema does not describe %q", name)
+		}
+	}
+}
+
+func jsonFields(typ reflect.Type) []string {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || typ.PkgPath() != reflect.TypeOf(JSONOutput{}).PkgPath() {
+		return nil
+	}
+
+	var fields []string
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		fields = append(fields, name)
+		fields = append(fields, jsonFields(typ.Field(i).Type)...)
+	}
+	return fields
+}
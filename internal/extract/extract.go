@@ -0,0 +1,84 @@
+// Package extract pulls the text a person typed out of structured files,
+// such as the cell sources of a Jupyter notebook, before it is counted.
+package extract
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Extractor returns the part of a file's content that should be counted.
+type Extractor interface {
+	// Name identifies the extractor in logs
+	Name() string
+	// Matches reports whether the extractor handles the file at path
+	Matches(path string) bool
+	// Extract returns the text to count from the file's content
+	Extract(content []byte) ([]byte, error)
+}
+
+// Registry picks the extractor for a file. Files no extractor matches are
+// counted as they are.
+type Registry struct {
+	extractors []Extractor
+}
+
+func NewRegistry(extractors ...Extractor) *Registry {
+	return &Registry{extractors: extractors}
+}
+
+// Register adds an extractor. Extractors registered earlier win when more
+// than one matches a file.
+func (r *Registry) Register(extractor Extractor) {
+	r.extractors = append(r.extractors, extractor)
+}
+
+// For returns the extractor for the file at path, or nil. A nil Registry
+// has no extractors.
+func (r *Registry) For(path string) Extractor {
+	if r == nil {
+		return nil
+	}
+	for _, extractor := range r.extractors {
+		if extractor.Matches(path) {
+			return extractor
+		}
+	}
+	return nil
+}
+
+// Default returns the built-in extractors: notebooks, and Markdown unless
+// the mode counts all of it.
+func Default(markdown MarkdownMode) *Registry {
+	registry := NewRegistry(Notebook{Cells: markdown})
+	if markdown != MarkdownAll {
+		registry.Register(Markdown{Mode: markdown})
+	}
+	return registry
+}
+
+// MarkdownMode selects which parts of Markdown are counted: the fenced code
+// blocks, the prose around them, or everything.
+type MarkdownMode string
+
+const (
+	MarkdownAll       MarkdownMode = "all"
+	MarkdownCodeOnly  MarkdownMode = "code-only"
+	MarkdownProseOnly MarkdownMode = "prose-only"
+)
+
+// MarkdownModes are the names ParseMarkdownMode accepts.
+var MarkdownModes = []MarkdownMode{MarkdownAll, MarkdownCodeOnly, MarkdownProseOnly}
+
+func ParseMarkdownMode(name string) (MarkdownMode, error) {
+	if slices.Contains(MarkdownModes, MarkdownMode(name)) {
+		return MarkdownMode(name), nil
+	}
+	return "", fmt.Errorf("unknown markdown mode %q", name)
+}
+
+func hasExtension(path string, extensions ...string) bool {
+	return slices.Contains(extensions, strings.ToLower(filepath.Ext(path)))
+}
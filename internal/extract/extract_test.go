@@ -0,0 +1,87 @@
+package extract
+
+import (
+	"testing"
+)
+
+const testNotebook = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Title\n", "Some prose"]},
+  {"cell_type": "code", "execution_count": 1, "metadata": {}, "outputs": [{"output_type": "stream", "text": ["noise\n"]}],
+   "source": ["import os\n", "print(\"hi\")"]},
+  {"cell_type": "code", "metadata": {}, "outputs": [], "source": "x = 1"}
+ ],
+ "metadata": {"kernelspec": {"name": "python3"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}`
+
+func TestNotebook(t *testing.T) {
+	tests := []struct {
+		cells    MarkdownMode
+		expected string
+	}{
+		{MarkdownAll, "# Title\nSome prose\nimport os\nprint(\"hi\")\nx = 1"},
+		{MarkdownCodeOnly, "import os\nprint(\"hi\")\nx = 1"},
+		{MarkdownProseOnly, "# Title\nSome prose"},
+	}
+
+	for _, tt := range tests {
+		out, err := Notebook{Cells: tt.cells}.Extract([]byte(testNotebook))
+		if err != nil {
+			t.Fatalf("Extract failed: %v", err)
+		}
+		if string(out) != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.cells, tt.expected, out)
+		}
+	}
+
+	if _, err := (Notebook{}).Extract([]byte("not json")); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
+
+const testMarkdown = "# Usage\n\nRun it:\n\n```sh\nmake build\n```\n\n  ~~~~python\n  print(1)\n  ```\n  ~~~~\nDone ``` inline\n````\nunclosed\n"
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		mode     MarkdownMode
+		expected string
+	}{
+		{MarkdownAll, testMarkdown},
+		{MarkdownCodeOnly, "make build\nprint(1)\n```\nunclosed\n"},
+		{MarkdownProseOnly, "# Usage\n\nRun it:\n\n\nDone ``` inline\n"},
+	}
+
+	for _, tt := range tests {
+		out, err := Markdown{Mode: tt.mode}.Extract([]byte(testMarkdown))
+		if err != nil {
+			t.Fatalf("Extract failed: %v", err)
+		}
+		if string(out) != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.mode, tt.expected, out)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := Default(MarkdownAll)
+	if e := registry.For("analysis.IPYNB"); e == nil || e.Name() != "notebook" {
+		t.Errorf("Expected the notebook extractor, got %v", e)
+	}
+	if e := registry.For("README.md"); e != nil {
+		t.Errorf("Expected no extractor for Markdown in all mode, got %s", e.Name())
+	}
+	if e := Default(MarkdownCodeOnly).For("docs/guide.markdown"); e == nil || e.Name() != "markdown" {
+		t.Errorf("Expected the markdown extractor, got %v", e)
+	}
+
+	var none *Registry
+	if none.For("a.ipynb") != nil {
+		t.Error("Expected a nil registry to have no extractors")
+	}
+
+	if _, err := ParseMarkdownMode("code"); err == nil {
+		t.Error("Expected an error for an unknown markdown mode")
+	}
+}
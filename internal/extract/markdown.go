@@ -0,0 +1,101 @@
+package extract
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Markdown splits Markdown into the contents of fenced code blocks and the
+// prose around them. Fence lines belong to neither.
+type Markdown struct {
+	Mode MarkdownMode
+}
+
+func (Markdown) Name() string { return "markdown" }
+
+func (Markdown) Matches(path string) bool {
+	return hasExtension(path, ".md", ".markdown", ".mdx")
+}
+
+func (m Markdown) Extract(content []byte) ([]byte, error) {
+	if m.Mode == MarkdownAll || m.Mode == "" {
+		return content, nil
+	}
+
+	var out bytes.Buffer
+	var open fence
+	inCode := false
+	for line := range bytes.Lines(content) {
+		text := strings.TrimRight(string(line), "\r\n")
+
+		if !inCode {
+			if f, ok := openingFence(text); ok {
+				open, inCode = f, true
+				continue
+			}
+			if m.Mode == MarkdownProseOnly {
+				out.Write(line)
+			}
+			continue
+		}
+
+		if open.closedBy(text) {
+			inCode = false
+			continue
+		}
+		if m.Mode == MarkdownCodeOnly {
+			out.WriteString(trimIndent(string(line), open.indent))
+		}
+	}
+	return out.Bytes(), nil
+}
+
+type fence struct {
+	char   byte
+	length int
+	indent int
+}
+
+// openingFence parses a CommonMark code fence: up to three spaces, then at
+// least three backticks or tildes. Backtick fences cannot have backticks in
+// their info string.
+func openingFence(line string) (fence, bool) {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if indent > 3 {
+		return fence{}, false
+	}
+	rest := line[indent:]
+	if rest == "" || rest[0] != '`' && rest[0] != '~' {
+		return fence{}, false
+	}
+
+	char := rest[0]
+	length := len(rest) - len(strings.TrimLeft(rest, string(char)))
+	if length < 3 {
+		return fence{}, false
+	}
+	if char == '`' && strings.Contains(rest[length:], "`") {
+		return fence{}, false
+	}
+	return fence{char: char, length: length, indent: indent}, true
+}
+
+// closedBy reports whether line closes the fence: the same character at
+// least as many times, with nothing but whitespace after it.
+func (f fence) closedBy(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+	length := len(trimmed) - len(strings.TrimLeft(trimmed, string(f.char)))
+	return length >= f.length && strings.TrimSpace(trimmed[length:]) == ""
+}
+
+// trimIndent removes up to indent leading spaces, as CommonMark does for the
+// contents of an indented fence.
+func trimIndent(line string, indent int) string {
+	for i := 0; i < indent && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+	return line
+}
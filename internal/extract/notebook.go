@@ -0,0 +1,84 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Notebook extracts the cell sources of a Jupyter notebook, leaving out the
+// JSON structure, metadata and outputs.
+type Notebook struct {
+	// Cells selects the cells like Markdown files: code-only keeps code
+	// cells, prose-only keeps markdown cells, and all keeps every cell
+	Cells MarkdownMode
+}
+
+func (Notebook) Name() string { return "notebook" }
+
+func (Notebook) Matches(path string) bool {
+	return hasExtension(path, ".ipynb")
+}
+
+type notebook struct {
+	Cells []struct {
+		CellType string          `json:"cell_type"`
+		Source   json.RawMessage `json:"source"`
+	} `json:"cells"`
+}
+
+func (n Notebook) Extract(content []byte) ([]byte, error) {
+	var parsed notebook
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	var out bytes.Buffer
+	for _, cell := range parsed.Cells {
+		if !n.keeps(cell.CellType) {
+			continue
+		}
+		source, err := cellSource(cell.Source)
+		if err != nil {
+			return nil, err
+		}
+		if out.Len() > 0 {
+			out.WriteByte('\n')
+		}
+		out.WriteString(source)
+	}
+	return out.Bytes(), nil
+}
+
+func (n Notebook) keeps(cellType string) bool {
+	switch n.Cells {
+	case MarkdownCodeOnly:
+		return cellType == "code"
+	case MarkdownProseOnly:
+		return cellType == "markdown"
+	}
+	return true
+}
+
+// cellSource joins a source that nbformat stores either as one string or
+// as a list of lines that keep their newlines.
+func cellSource(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err == nil {
+		var source bytes.Buffer
+		for _, line := range lines {
+			source.WriteString(line)
+		}
+		return source.String(), nil
+	}
+
+	var source string
+	if err := json.Unmarshal(raw, &source); err != nil {
+		return "", fmt.Errorf("invalid cell source: %w", err)
+	}
+	return source, nil
+}
@@ -0,0 +1,177 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ogdakke/symbolista/internal/logger"
+)
+
+// BlameStats counts the lines blame attributed to the selected authors and
+// the lines it skipped because someone else wrote them.
+type BlameStats struct {
+	AttributedLines int
+	SkippedLines    int
+}
+
+// AuthoredLines runs git blame on each path and keeps only the lines whose
+// author email is one of authors, compared case-insensitively. Lines that
+// are not committed yet belong to nobody. Files that cannot be blamed, such
+// as untracked files, are skipped.
+func (r *Repository) AuthoredLines(paths []string, authors []string, workers int) ([]FileLines, BlameStats, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, BlameStats{}, fmt.Errorf("author filtering needs the git binary: %w", err)
+	}
+
+	emails := make(map[string]bool, len(authors))
+	for _, author := range authors {
+		emails[normalizeEmail(author)] = true
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	type blameResult struct {
+		lines FileLines
+		stats BlameStats
+		ok    bool
+	}
+	results := make([]blameResult, len(paths))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				content, stats, err := r.blameFile(paths[i], emails)
+				if err != nil {
+					logger.Debug("Cannot blame file", "path", paths[i], "error", err)
+					continue
+				}
+				results[i] = blameResult{
+					lines: FileLines{Path: paths[i], Content: content, Lines: stats.AttributedLines},
+					stats: stats,
+					ok:    true,
+				}
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var files []FileLines
+	var total BlameStats
+	for _, result := range results {
+		if !result.ok {
+			continue
+		}
+		total.AttributedLines += result.stats.AttributedLines
+		total.SkippedLines += result.stats.SkippedLines
+		if result.lines.Lines > 0 {
+			files = append(files, result.lines)
+		}
+	}
+
+	logger.Debug("Blamed files", "files", len(paths), "with_attributed_lines", len(files), "attributed_lines", total.AttributedLines, "skipped_lines", total.SkippedLines)
+	return files, total, nil
+}
+
+func (r *Repository) blameFile(path string, emails map[string]bool) ([]byte, BlameStats, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, BlameStats{}, err
+	}
+
+	cmd := exec.Command("git", "blame", "--porcelain", "--", absPath)
+	cmd.Dir = r.WorkTree
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, BlameStats{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, BlameStats{}, err
+	}
+
+	content, stats, parseErr := ParseBlame(stdout, emails)
+	io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return nil, BlameStats{}, fmt.Errorf("git blame failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return content, stats, parseErr
+}
+
+// ParseBlame reads git blame --porcelain output and returns the lines whose
+// author email is in emails, which must be normalized with normalizeEmail.
+func ParseBlame(r io.Reader, emails map[string]bool) ([]byte, BlameStats, error) {
+	var content []byte
+	var stats BlameStats
+
+	// Porcelain output only lists a commit's details the first time it appears
+	commitEmails := make(map[string]string)
+	var commit string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if text, ok := strings.CutPrefix(line, "\t"); ok {
+			if emails[commitEmails[commit]] {
+				content = append(content, text...)
+				content = append(content, '\n')
+				stats.AttributedLines++
+			} else {
+				stats.SkippedLines++
+			}
+			continue
+		}
+
+		if email, ok := strings.CutPrefix(line, "author-mail "); ok {
+			commitEmails[commit] = normalizeEmail(email)
+			continue
+		}
+
+		if id, _, ok := strings.Cut(line, " "); ok && isObjectID(id) {
+			commit = id
+		}
+	}
+
+	return content, stats, scanner.Err()
+}
+
+func normalizeEmail(email string) string {
+	email = strings.TrimSpace(email)
+	email = strings.TrimPrefix(email, "<")
+	email = strings.TrimSuffix(email, ">")
+	return strings.ToLower(email)
+}
+
+// isObjectID reports whether s is a SHA-1 or SHA-256 object id in hex.
+func isObjectID(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
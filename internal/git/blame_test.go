@@ -0,0 +1,84 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseBlame(t *testing.T) {
+	porcelain := `1111111111111111111111111111111111111111 1 1 2
+author Test
+author-mail <Test@Example.com>
+summary first
+filename main.go
+	package main
+1111111111111111111111111111111111111111 2 2
+	
+2222222222222222222222222222222222222222 3 3 1
+author Other
+author-mail <other@example.com>
+summary second
+filename main.go
+	func main() {}
+1111111111111111111111111111111111111111 4 4 1
+	// author-mail <other@example.com>
+0000000000000000000000000000000000000000 5 5 1
+author Not Committed Yet
+author-mail <not.committed.yet>
+filename main.go
+	// wip
+`
+
+	content, stats, err := ParseBlame(strings.NewReader(porcelain), map[string]bool{"test@example.com": true})
+	if err != nil {
+		t.Fatalf("ParseBlame failed: %v", err)
+	}
+
+	expected := "package main\n\n// author-mail <other@example.com>\n"
+	if string(content) != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+	if stats.AttributedLines != 3 || stats.SkippedLines != 2 {
+		t.Errorf("Expected 3 attributed and 2 skipped lines, got %+v", stats)
+	}
+}
+
+func TestAuthoredLines(t *testing.T) {
+	repoDir := initTestRepo(t)
+	runGit(t, repoDir, "commit", "-q", "-m", "initial")
+
+	mainPath := filepath.Join(repoDir, "main.go")
+	if err := os.WriteFile(mainPath, []byte("package main\n\nfunc other() {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write main.go: %v", err)
+	}
+	runGit(t, repoDir, "commit", "-q", "-am", "other", "--author", "Other <other@example.com>")
+
+	repo, err := FindRepository(repoDir)
+	if err != nil {
+		t.Fatalf("FindRepository failed: %v", err)
+	}
+
+	paths := []string{mainPath, filepath.Join(repoDir, "README.md"), filepath.Join(repoDir, "scratch.txt")}
+
+	files, stats, err := repo.AuthoredLines(paths, []string{"<OTHER@example.com>"}, 2)
+	if err != nil {
+		t.Fatalf("AuthoredLines failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != mainPath || string(files[0].Content) != "\nfunc other() {}\n" {
+		t.Errorf("Expected only the lines by other in main.go, got %+v", files)
+	}
+	// scratch.txt is untracked and cannot be blamed
+	if stats.AttributedLines != 2 || stats.SkippedLines != 2 {
+		t.Errorf("Expected 2 attributed and 2 skipped lines, got %+v", stats)
+	}
+
+	files, stats, err = repo.AuthoredLines(paths, []string{"test@example.com", "other@example.com"}, 1)
+	if err != nil {
+		t.Fatalf("AuthoredLines failed: %v", err)
+	}
+	if len(files) != 2 || stats.AttributedLines != 4 || stats.SkippedLines != 0 {
+		t.Errorf("Expected all lines of both tracked files, got %+v %+v", files, stats)
+	}
+}
@@ -0,0 +1,312 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ogdakke/symbolista/internal/logger"
+)
+
+// DiffSpec selects the changes whose added lines are counted.
+type DiffSpec struct {
+	// Range is a revision range such as "main..HEAD" or "HEAD~10..". Every
+	// commit in the range is diffed against its parent, so lines that were
+	// typed and later changed again are counted each time.
+	Range string
+	// Staged includes changes staged in the index.
+	Staged bool
+	// Worktree includes changes in the working tree that are not staged, and
+	// every line of untracked files that are not ignored.
+	Worktree bool
+}
+
+// IsZero reports whether no changes are selected.
+func (s DiffSpec) IsZero() bool {
+	return s.Range == "" && !s.Staged && !s.Worktree
+}
+
+func (s DiffSpec) String() string {
+	switch {
+	case s.Range != "":
+		return s.Range
+	case s.Staged && s.Worktree:
+		return "uncommitted changes"
+	case s.Staged:
+		return "staged changes"
+	default:
+		return "working tree changes"
+	}
+}
+
+// FileLines holds selected lines of one file, such as the lines added to it.
+type FileLines struct {
+	Path    string
+	Content []byte
+	Lines   int
+}
+
+// AddedLines runs git to collect the lines the spec added below dir. The
+// returned paths are joined to dir, like TrackedFiles. Binary files and
+// deletions are skipped, and renames without changes add nothing. With
+// Worktree, untracked files count as added in full.
+func (r *Repository) AddedLines(dir string, spec DiffSpec) ([]FileLines, error) {
+	if spec.IsZero() {
+		return nil, fmt.Errorf("no changes selected")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git diff mode needs the git binary: %w", err)
+	}
+
+	prefix, err := r.pathPrefix(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var subcommand []string
+	switch {
+	case spec.Range != "":
+		if strings.HasPrefix(spec.Range, "-") {
+			return nil, fmt.Errorf("invalid revision range %q", spec.Range)
+		}
+		subcommand = []string{"log", "--patch", "--no-merges", "--format=", spec.Range}
+	case spec.Staged && spec.Worktree:
+		subcommand = []string{"diff", "HEAD"}
+	case spec.Staged:
+		subcommand = []string{"diff", "--cached"}
+	default:
+		subcommand = []string{"diff"}
+	}
+
+	// The prefixes are set explicitly as diff.noprefix and
+	// diff.mnemonicPrefix change them, and diffPath expects "b/"
+	args := []string{"-c", "core.quotePath=false", "-c", "diff.noprefix=false", "-c", "diff.mnemonicPrefix=false"}
+	args = append(args, subcommand...)
+	args = append(args, "--unified=0", "--no-color", "--no-ext-diff", "--no-textconv", "--find-renames",
+		"--src-prefix=a/", "--dst-prefix=b/", "--")
+	if prefix != "" {
+		args = append(args, prefix)
+	}
+
+	logger.Debug("Reading added lines from git", "work_tree", r.WorkTree, "args", args)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.WorkTree
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	files, parseErr := ParseAddedLines(stdout)
+	// Drain the rest so git does not block on a full pipe
+	io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("git %s failed: %w: %s", subcommand[0], err, strings.TrimSpace(stderr.String()))
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	if spec.Worktree {
+		untracked, err := r.untrackedFiles(prefix)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, untracked...)
+	}
+
+	var result []FileLines
+	for _, file := range files {
+		rel, ok := strings.CutPrefix(file.Path, prefix)
+		if !ok {
+			continue
+		}
+		file.Path = filepath.Join(dir, filepath.FromSlash(rel))
+		result = append(result, file)
+	}
+
+	logger.Debug("Added lines read from git", "spec", spec.String(), "files", len(result))
+	return result, nil
+}
+
+// untrackedFiles reads the untracked files below prefix that are not
+// ignored. git diff does not show them, but all their lines are new.
+func (r *Repository) untrackedFiles(prefix string) ([]FileLines, error) {
+	args := []string{"ls-files", "-z", "--others", "--exclude-standard", "--"}
+	if prefix != "" {
+		args = append(args, prefix)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.WorkTree
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var files []FileLines
+	for path := range strings.SplitSeq(string(out), "\x00") {
+		if path == "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(r.WorkTree, filepath.FromSlash(path)))
+		if err != nil {
+			logger.Debug("Skipping unreadable untracked file", "path", path, "error", err)
+			continue
+		}
+		// Binary files are skipped like in the diff, by git's own test for a
+		// NUL byte near the start
+		if bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+			continue
+		}
+
+		lines := bytes.Count(content, []byte("\n"))
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			lines++
+		}
+		files = append(files, FileLines{Path: path, Content: content, Lines: lines})
+	}
+	return files, nil
+}
+
+// ParseAddedLines reads unified diffs, as printed by git diff or git log
+// --patch, and returns the added lines per file in order of first
+// appearance. Lines added to the same path by several diffs are combined.
+func ParseAddedLines(r io.Reader) ([]FileLines, error) {
+	var files []FileLines
+	index := make(map[string]int)
+
+	current := -1
+	// Lines left in the current hunk, from its @@ header
+	var oldLeft, newLeft int
+	var lastAdded bool
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+				if current >= 0 {
+					files[current].Content = append(files[current].Content, line[1:]...)
+					files[current].Content = append(files[current].Content, '\n')
+					files[current].Lines++
+				}
+				lastAdded = true
+				continue
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+				lastAdded = false
+				continue
+			case strings.HasPrefix(line, " "):
+				oldLeft--
+				newLeft--
+				lastAdded = false
+				continue
+			case strings.HasPrefix(line, `\`):
+				continue
+			}
+			// A malformed hunk; fall through to header handling
+			oldLeft, newLeft = 0, 0
+		}
+
+		switch {
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" after the last line of a hunk
+			if lastAdded && current >= 0 {
+				content := files[current].Content
+				files[current].Content = content[:len(content)-1]
+			}
+			lastAdded = false
+		case strings.HasPrefix(line, "diff --git "):
+			current = -1
+			lastAdded = false
+		case strings.HasPrefix(line, "+++ "):
+			path, ok := diffPath(line[len("+++ "):])
+			if !ok {
+				// Deleted file
+				current = -1
+				continue
+			}
+			i, seen := index[path]
+			if !seen {
+				i = len(files)
+				index[path] = i
+				files = append(files, FileLines{Path: path})
+			}
+			current = i
+		case strings.HasPrefix(line, "@@ "):
+			var err error
+			oldLeft, newLeft, err = parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			lastAdded = false
+		}
+	}
+
+	return files, scanner.Err()
+}
+
+// diffPath returns the path from a "+++ b/path" line, or false for /dev/null.
+func diffPath(name string) (string, bool) {
+	if strings.HasPrefix(name, `"`) {
+		unquoted, err := strconv.Unquote(name)
+		if err == nil {
+			name = unquoted
+		}
+	}
+	if name == "/dev/null" {
+		return "", false
+	}
+	name = strings.TrimSuffix(name, "\t")
+	if rest, ok := strings.CutPrefix(name, "b/"); ok {
+		return rest, true
+	}
+	return name, true
+}
+
+// parseHunkHeader reads the line counts from "@@ -old,count +new,count @@".
+// A missing count means one line.
+func parseHunkHeader(line string) (int, int, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0, fmt.Errorf("invalid hunk header %q", line)
+	}
+	oldCount, err := hunkCount(fields[1], "-")
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+	newCount, err := hunkCount(fields[2], "+")
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+	return oldCount, newCount, nil
+}
+
+func hunkCount(field, sign string) (int, error) {
+	rangeSpec, ok := strings.CutPrefix(field, sign)
+	if !ok {
+		return 0, fmt.Errorf("expected %s range", sign)
+	}
+	_, count, found := strings.Cut(rangeSpec, ",")
+	if !found {
+		return 1, nil
+	}
+	return strconv.Atoi(count)
+}
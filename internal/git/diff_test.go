@@ -0,0 +1,223 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAddedLines(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1 +1,2 @@
+-package old
++package main
+++++ not a header
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git "a/caf\303\251.txt" "b/caf\303\251.txt"
+new file mode 100644
+--- /dev/null
++++ "b/caf\303\251.txt"
+@@ -0,0 +1 @@
++no newline
+\ No newline at end of file
+diff --git a/image.png b/image.png
+Binary files a/image.png and b/image.png differ
+
+diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -3,0 +4 @@ func main() {
++	return
+`
+
+	files, err := ParseAddedLines(strings.NewReader(diff))
+	if err != nil {
+		t.Fatalf("ParseAddedLines failed: %v", err)
+	}
+
+	expected := []FileLines{
+		{Path: "main.go", Content: []byte("package main\n+++ not a header\n\treturn\n"), Lines: 3},
+		{Path: "café.txt", Content: []byte("no newline"), Lines: 1},
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %d files, got %d: %+v", len(expected), len(files), files)
+	}
+	for i, file := range files {
+		if file.Path != expected[i].Path || string(file.Content) != string(expected[i].Content) || file.Lines != expected[i].Lines {
+			t.Errorf("Expected %+v, got %+v", expected[i], file)
+		}
+	}
+}
+
+func TestAddedLines(t *testing.T) {
+	repoDir := initTestRepo(t)
+	runGit(t, repoDir, "commit", "-q", "-m", "initial")
+
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoDir, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	writeFile("main.go", "package main\n\nfunc main() {}\n")
+	runGit(t, repoDir, "commit", "-q", "-am", "add main")
+	writeFile("src/utils.go", "package src\n\nvar x = 1\n")
+	runGit(t, repoDir, "commit", "-q", "-am", "add var")
+	runGit(t, repoDir, "mv", "README.md", "README.txt")
+	runGit(t, repoDir, "commit", "-q", "-m", "rename")
+
+	writeFile("src/nested/deep/file.ts", "export const a = 1;\nexport const staged = true;\n")
+	runGit(t, repoDir, "add", "src/nested/deep/file.ts")
+	writeFile("main.go", "package main\n\nfunc main() {}\n// unstaged\n")
+	// Untracked files count in full, unless they are ignored or binary
+	writeFile("src/new.txt", "new\nfile")
+	writeFile("src/blob.bin", "\x00\x01")
+	writeFile("src/debug.log", "ignored\n")
+	writeFile(".git/info/exclude", "*.log\n")
+
+	repo, err := FindRepository(repoDir)
+	if err != nil {
+		t.Fatalf("FindRepository failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		dir      string
+		spec     DiffSpec
+		expected map[string]string
+	}{
+		{
+			name: "range",
+			dir:  repoDir,
+			spec: DiffSpec{Range: "HEAD~3..HEAD"},
+			expected: map[string]string{
+				"main.go":      "\nfunc main() {}\n",
+				"src/utils.go": "\nvar x = 1\n",
+			},
+		},
+		{
+			name:     "range in subdirectory",
+			dir:      filepath.Join(repoDir, "src"),
+			spec:     DiffSpec{Range: "HEAD~3.."},
+			expected: map[string]string{"utils.go": "\nvar x = 1\n"},
+		},
+		{
+			name:     "staged",
+			dir:      repoDir,
+			spec:     DiffSpec{Staged: true},
+			expected: map[string]string{"src/nested/deep/file.ts": "export const staged = true;\n"},
+		},
+		{
+			name:     "worktree",
+			dir:      repoDir,
+			spec:     DiffSpec{Worktree: true},
+			expected: map[string]string{"main.go": "// unstaged\n", "scratch.txt": "scratch", "src/new.txt": "new\nfile"},
+		},
+		{
+			name:     "worktree in subdirectory",
+			dir:      filepath.Join(repoDir, "src"),
+			spec:     DiffSpec{Worktree: true},
+			expected: map[string]string{"new.txt": "new\nfile"},
+		},
+		{
+			name: "staged and worktree",
+			dir:  repoDir,
+			spec: DiffSpec{Staged: true, Worktree: true},
+			expected: map[string]string{
+				"main.go":                 "// unstaged\n",
+				"scratch.txt":             "scratch",
+				"src/new.txt":             "new\nfile",
+				"src/nested/deep/file.ts": "export const staged = true;\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := repo.AddedLines(tt.dir, tt.spec)
+			if err != nil {
+				t.Fatalf("AddedLines failed: %v", err)
+			}
+
+			got := make(map[string]string)
+			for _, file := range files {
+				rel, err := filepath.Rel(tt.dir, file.Path)
+				if err != nil {
+					t.Fatalf("Unexpected path %s: %v", file.Path, err)
+				}
+				got[filepath.ToSlash(rel)] = string(file.Content)
+			}
+
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for path, content := range tt.expected {
+				if got[path] != content {
+					t.Errorf("Expected %q for %s, got %q", content, path, got[path])
+				}
+			}
+		})
+	}
+
+	if _, err := repo.AddedLines(repoDir, DiffSpec{Range: "does-not-exist..HEAD"}); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+}
+
+func TestAddedLinesDiffPrefixConfig(t *testing.T) {
+	repoDir := initTestRepo(t)
+	runGit(t, repoDir, "commit", "-q", "-m", "initial")
+
+	// A top level directory named b/ must keep its name without prefixes
+	if err := os.MkdirAll(filepath.Join(repoDir, "b"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "b", "x.go"), []byte("package b\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "src", "utils.go"), []byte("package src\nvar y = 2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	runGit(t, repoDir, "add", "-A")
+
+	for _, config := range []string{"diff.mnemonicPrefix", "diff.noprefix"} {
+		t.Run(config, func(t *testing.T) {
+			runGit(t, repoDir, "config", config, "true")
+			defer runGit(t, repoDir, "config", "--unset", config)
+
+			repo, err := FindRepository(repoDir)
+			if err != nil {
+				t.Fatalf("FindRepository failed: %v", err)
+			}
+
+			for _, dir := range []string{repoDir, filepath.Join(repoDir, "src")} {
+				files, err := repo.AddedLines(dir, DiffSpec{Staged: true})
+				if err != nil {
+					t.Fatalf("AddedLines failed: %v", err)
+				}
+
+				got := make(map[string]string)
+				for _, file := range files {
+					rel, _ := filepath.Rel(repoDir, file.Path)
+					got[filepath.ToSlash(rel)] = string(file.Content)
+				}
+				if got["src/utils.go"] != "var y = 2\n" {
+					t.Errorf("Expected the added line in src/utils.go from %s, got %v", dir, got)
+				}
+				if dir == repoDir && got["b/x.go"] != "package b\n" {
+					t.Errorf("Expected b/x.go to keep its directory, got %v", got)
+				}
+			}
+		})
+	}
+}
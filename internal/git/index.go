@@ -0,0 +1,198 @@
+package git
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ogdakke/symbolista/internal/logger"
+)
+
+const (
+	indexSignature = "DIRC"
+
+	// ctime, mtime, dev, ino, mode, uid, gid, size, object id and flags
+	indexEntryFixedSize = 62
+
+	flagNameMask = 0x0fff
+	flagExtended = 0x4000
+	flagStage    = 0x3000
+
+	modeTypeMask    = 0xf000
+	modeTypeRegular = 0x8000
+)
+
+// IndexEntry is a single path recorded in the git index.
+type IndexEntry struct {
+	Path string
+	Mode uint32
+	Size uint32
+	Hash [20]byte
+	// Conflicted is set for paths with a merge conflict. They have no single
+	// staged version, so Size and Hash are unset and the working tree file
+	// is the content.
+	Conflicted bool
+}
+
+// IsRegular reports whether the entry is a regular file, as opposed to a
+// symlink or a submodule.
+func (e IndexEntry) IsRegular() bool {
+	return e.Mode&modeTypeMask == modeTypeRegular
+}
+
+// ReadIndex parses the index file of the repository. Versions 2, 3 and 4 are
+// supported. Paths with merge conflicts have an entry for each side in the
+// index, and are returned once as a Conflicted entry.
+func (r *Repository) ReadIndex() ([]IndexEntry, error) {
+	data, err := os.ReadFile(filepath.Join(r.GitDir, "index"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseIndex(data)
+}
+
+// ParseIndex parses the raw contents of a git index file.
+func ParseIndex(data []byte) ([]IndexEntry, error) {
+	if len(data) < 12 || string(data[:4]) != indexSignature {
+		return nil, errors.New("invalid index signature")
+	}
+
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
+
+	count := binary.BigEndian.Uint32(data[8:12])
+	logger.Debug("Parsing git index", "version", version, "entries", count)
+
+	entries := make([]IndexEntry, 0, count)
+	offset := 12
+	previousPath := ""
+
+	for i := uint32(0); i < count; i++ {
+		if offset+indexEntryFixedSize > len(data) {
+			return nil, fmt.Errorf("index entry %d is truncated", i)
+		}
+
+		entryStart := offset
+		var entry IndexEntry
+		entry.Mode = binary.BigEndian.Uint32(data[offset+24 : offset+28])
+		entry.Size = binary.BigEndian.Uint32(data[offset+36 : offset+40])
+		copy(entry.Hash[:], data[offset+40:offset+60])
+		flags := binary.BigEndian.Uint16(data[offset+60 : offset+62])
+		offset += indexEntryFixedSize
+
+		if flags&flagExtended != 0 {
+			if version < 3 {
+				return nil, fmt.Errorf("index entry %d has extended flags in version %d", i, version)
+			}
+			offset += 2
+		}
+
+		if version == 4 {
+			strip, n, err := readOffsetVarint(data[offset:])
+			if err != nil {
+				return nil, fmt.Errorf("index entry %d: %w", i, err)
+			}
+			offset += n
+
+			if int(strip) > len(previousPath) {
+				return nil, fmt.Errorf("index entry %d strips more than the previous path", i)
+			}
+
+			end := bytes.IndexByte(data[offset:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("index entry %d has an unterminated path", i)
+			}
+			entry.Path = previousPath[:len(previousPath)-int(strip)] + string(data[offset:offset+end])
+			offset += end + 1
+		} else {
+			nameLength := int(flags & flagNameMask)
+			var end int
+			if nameLength < flagNameMask {
+				end = nameLength
+			} else {
+				end = bytes.IndexByte(data[offset:], 0)
+			}
+			if end < 0 || offset+end > len(data) {
+				return nil, fmt.Errorf("index entry %d has an unterminated path", i)
+			}
+			entry.Path = string(data[offset : offset+end])
+
+			// Entries are NUL padded to a multiple of eight bytes, with at least one NUL.
+			entryLength := offset + end - entryStart
+			offset = entryStart + (entryLength+8)&^7
+		}
+
+		previousPath = entry.Path
+
+		if flags&flagStage != 0 {
+			// The stages of a path are sorted next to each other
+			if len(entries) > 0 && entries[len(entries)-1].Path == entry.Path {
+				continue
+			}
+			entry.Conflicted = true
+			entry.Size = 0
+			entry.Hash = [20]byte{}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// TrackedFiles returns the regular files from the index that live under dir,
+// as paths joined onto dir. Their content is read from the working tree, which
+// for a conflicted path holds the conflict markers.
+func (r *Repository) TrackedFiles(dir string) ([]string, error) {
+	entries, err := r.ReadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := r.pathPrefix(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsRegular() {
+			logger.Trace("Skipping non-regular index entry", "path", entry.Path, "mode", fmt.Sprintf("%o", entry.Mode))
+			continue
+		}
+		rel, ok := strings.CutPrefix(entry.Path, prefix)
+		if !ok {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, filepath.FromSlash(rel)))
+	}
+
+	logger.Debug("Tracked files listed from index", "dir", dir, "files", len(paths), "index_entries", len(entries))
+	return paths, nil
+}
+
+// readOffsetVarint decodes the variable length integer used by index
+// version 4 for path prefix compression.
+func readOffsetVarint(data []byte) (uint64, int, error) {
+	if len(data) == 0 {
+		return 0, 0, errors.New("truncated varint")
+	}
+
+	c := data[0]
+	value := uint64(c & 0x7f)
+	n := 1
+	for c&0x80 != 0 {
+		if n >= len(data) {
+			return 0, 0, errors.New("truncated varint")
+		}
+		c = data[n]
+		n++
+		value = ((value + 1) << 7) | uint64(c&0x7f)
+	}
+	return value, n, nil
+}
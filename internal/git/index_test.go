@@ -0,0 +1,191 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":                   "package main\n",
+		"README.md":                 "# readme\n",
+		"src/utils.go":              "package src\n",
+		"src/nested/deep/file.ts":   "export const a = 1;\n",
+		"src/nested/deep/other.ts":  "export const b = 2;\n",
+		"a-very-long-directory/x.c": "int main() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	runGit(t, tempDir, "init", "-q")
+	runGit(t, tempDir, "add", ".")
+
+	// Untracked file which must not show up in the index
+	if err := os.WriteFile(filepath.Join(tempDir, "scratch.txt"), []byte("scratch"), 0644); err != nil {
+		t.Fatalf("Failed to create scratch file: %v", err)
+	}
+
+	return tempDir
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func TestReadIndexVersions(t *testing.T) {
+	repoDir := initTestRepo(t)
+	expected := strings.Fields(runGit(t, repoDir, "ls-files"))
+
+	for _, version := range []string{"2", "3", "4"} {
+		t.Run("version "+version, func(t *testing.T) {
+			runGit(t, repoDir, "update-index", "--index-version", version)
+
+			repo, err := FindRepository(repoDir)
+			if err != nil {
+				t.Fatalf("FindRepository failed: %v", err)
+			}
+
+			entries, err := repo.ReadIndex()
+			if err != nil {
+				t.Fatalf("ReadIndex failed: %v", err)
+			}
+
+			var paths []string
+			for _, entry := range entries {
+				paths = append(paths, entry.Path)
+			}
+
+			if !slices.Equal(paths, expected) {
+				t.Errorf("Expected paths %v, got %v", expected, paths)
+			}
+		})
+	}
+}
+
+func TestReadIndexConflict(t *testing.T) {
+	repoDir := initTestRepo(t)
+	runGit(t, repoDir, "commit", "-q", "-m", "initial")
+	runGit(t, repoDir, "checkout", "-q", "-b", "other")
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package other\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	runGit(t, repoDir, "commit", "-q", "-am", "other")
+	runGit(t, repoDir, "checkout", "-q", "-")
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package ours\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	runGit(t, repoDir, "commit", "-q", "-am", "ours")
+
+	cmd := exec.Command("git", "merge", "-q", "other")
+	cmd.Dir = repoDir
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if out, err := cmd.CombinedOutput(); !strings.Contains(string(out), "CONFLICT") {
+		t.Fatalf("Expected the merge to conflict, got %v: %s", err, out)
+	}
+
+	repo, err := FindRepository(repoDir)
+	if err != nil {
+		t.Fatalf("FindRepository failed: %v", err)
+	}
+	entries, err := repo.ReadIndex()
+	if err != nil {
+		t.Fatalf("ReadIndex failed: %v", err)
+	}
+
+	var conflicted []IndexEntry
+	for _, entry := range entries {
+		if entry.Path == "main.go" {
+			conflicted = append(conflicted, entry)
+		} else if entry.Conflicted {
+			t.Errorf("Expected %s not to be conflicted", entry.Path)
+		}
+	}
+	if len(conflicted) != 1 || !conflicted[0].Conflicted || conflicted[0].Hash != [20]byte{} || !conflicted[0].IsRegular() {
+		t.Errorf("Expected main.go once as a conflicted regular file, got %+v", conflicted)
+	}
+}
+
+func TestTrackedFilesSubdirectory(t *testing.T) {
+	repoDir := initTestRepo(t)
+
+	subDir := filepath.Join(repoDir, "src")
+	repo, err := FindRepository(subDir)
+	if err != nil {
+		t.Fatalf("FindRepository failed: %v", err)
+	}
+
+	paths, err := repo.TrackedFiles(subDir)
+	if err != nil {
+		t.Fatalf("TrackedFiles failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(subDir, "nested", "deep", "file.ts"),
+		filepath.Join(subDir, "nested", "deep", "other.ts"),
+		filepath.Join(subDir, "utils.go"),
+	}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+}
+
+func TestFindRepositoryWithGitFile(t *testing.T) {
+	repoDir := initTestRepo(t)
+	runGit(t, repoDir, "commit", "-q", "-m", "initial")
+
+	worktreeDir := filepath.Join(t.TempDir(), "worktree")
+	runGit(t, repoDir, "worktree", "add", "-q", worktreeDir)
+
+	repo, err := FindRepository(worktreeDir)
+	if err != nil {
+		t.Fatalf("FindRepository failed: %v", err)
+	}
+
+	if repo.GitDir == filepath.Join(worktreeDir, ".git") {
+		t.Errorf("Expected .git file to be resolved, got %s", repo.GitDir)
+	}
+
+	expectedCommon, _ := filepath.EvalSymlinks(filepath.Join(repoDir, ".git"))
+	actualCommon, _ := filepath.EvalSymlinks(repo.CommonDir)
+	if actualCommon != expectedCommon {
+		t.Errorf("Expected common dir %s, got %s", expectedCommon, actualCommon)
+	}
+
+	paths, err := repo.TrackedFiles(worktreeDir)
+	if err != nil {
+		t.Fatalf("TrackedFiles failed: %v", err)
+	}
+	if len(paths) != 6 {
+		t.Errorf("Expected 6 tracked files in worktree, got %d", len(paths))
+	}
+}
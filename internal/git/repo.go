@@ -0,0 +1,111 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ogdakke/symbolista/internal/logger"
+)
+
+// Repository describes where a working tree and its git metadata live.
+type Repository struct {
+	// WorkTree is the top level directory of the checkout.
+	WorkTree string
+	// GitDir holds the index and HEAD for this working tree.
+	GitDir string
+	// CommonDir holds objects and refs. It differs from GitDir for linked worktrees.
+	CommonDir string
+}
+
+// FindRepository walks up from path until it finds a .git directory or a
+// .git file pointing elsewhere (linked worktrees and submodules).
+func FindRepository(path string) (*Repository, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := absPath
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		info, err := os.Stat(dotGit)
+		if err == nil {
+			var gitDir string
+			if info.IsDir() {
+				gitDir = dotGit
+			} else {
+				gitDir, err = readGitFile(dotGit)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			repo := &Repository{
+				WorkTree:  dir,
+				GitDir:    gitDir,
+				CommonDir: resolveCommonDir(gitDir),
+			}
+			logger.Debug("Found git repository", "work_tree", repo.WorkTree, "git_dir", repo.GitDir, "common_dir", repo.CommonDir)
+			return repo, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("not a git repository (or any of the parent directories): %s", path)
+		}
+		dir = parent
+	}
+}
+
+// readGitFile resolves a .git file of the form "gitdir: <path>".
+func readGitFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	line := strings.TrimSpace(string(data))
+	gitDir, ok := strings.CutPrefix(line, "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("invalid .git file %s", path)
+	}
+
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+func resolveCommonDir(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+
+	commonDir := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+	return filepath.Clean(commonDir)
+}
+
+// pathPrefix returns dir relative to the working tree as a slash separated
+// prefix of repository paths, "" for the top level and "sub/dir/" otherwise.
+func (r *Repository) pathPrefix(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	prefix, err := filepath.Rel(r.WorkTree, absDir)
+	if err != nil {
+		return "", err
+	}
+	prefix = filepath.ToSlash(prefix)
+	if prefix == "." {
+		return "", nil
+	}
+	return prefix + "/", nil
+}
@@ -0,0 +1,205 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ogdakke/symbolista/internal/logger"
+	"github.com/ogdakke/symbolista/internal/vfs"
+)
+
+// TreeFile is a file in a revision's tree.
+type TreeFile struct {
+	// Path is slash-separated and relative to the directory the tree was
+	// opened for, as in the tree's fs.FS
+	Path string
+	Mode fs.FileMode
+	Size int64
+	Hash string
+}
+
+// Tree is the part of a revision below a directory, as an fs.FS rooted at
+// that directory. Contents are read from the object database, without
+// touching the working tree.
+type Tree struct {
+	*vfs.FS
+	Rev      string
+	Files    []TreeFile
+	workTree string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// OpenTree lists the files of rev below dir. Symlinks are listed with their
+// mode but cannot be read, and submodules are skipped. Contents are read on
+// demand by a single git cat-file --batch process, which Close stops.
+func (r *Repository) OpenTree(dir, rev string) (*Tree, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("reading a revision needs the git binary: %w", err)
+	}
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return nil, fmt.Errorf("invalid revision %q", rev)
+	}
+
+	prefix, err := r.pathPrefix(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"ls-tree", "-r", "-z", "--long", "--full-tree", rev, "--"}
+	if prefix != "" {
+		args = append(args, prefix)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.WorkTree
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	tree := &Tree{FS: vfs.New(), Rev: rev, workTree: r.WorkTree}
+	for record := range strings.SplitSeq(string(out), "\x00") {
+		if record == "" {
+			continue
+		}
+		entry, ok := parseTreeRecord(record)
+		if !ok {
+			return nil, fmt.Errorf("unexpected git ls-tree output %q", record)
+		}
+		rel, ok := strings.CutPrefix(entry.path, prefix)
+		if !ok {
+			continue
+		}
+
+		file := TreeFile{Path: rel, Size: entry.size, Hash: entry.hash}
+		var read vfs.ReadFunc
+		switch entry.mode {
+		case "100644":
+			file.Mode = 0644
+		case "100755":
+			file.Mode = 0755
+		case "120000":
+			file.Mode = fs.ModeSymlink | 0777
+		default:
+			logger.Trace("Skipping tree entry", "path", entry.path, "mode", entry.mode)
+			continue
+		}
+		if file.Mode.IsRegular() {
+			read = func() ([]byte, error) { return tree.readBlob(file) }
+		}
+
+		if tree.Add(rel, file.Size, file.Mode, time.Time{}, read) {
+			tree.Files = append(tree.Files, file)
+		}
+	}
+
+	logger.Debug("Listed revision tree", "rev", rev, "dir", dir, "files", len(tree.Files))
+	return tree, nil
+}
+
+type treeRecord struct {
+	mode string
+	hash string
+	size int64
+	path string
+}
+
+// parseTreeRecord parses "<mode> <type> <object> <size>\t<path>".
+func parseTreeRecord(record string) (treeRecord, bool) {
+	meta, path, ok := strings.Cut(record, "\t")
+	if !ok {
+		return treeRecord{}, false
+	}
+	fields := strings.Fields(meta)
+	if len(fields) != 4 {
+		return treeRecord{}, false
+	}
+
+	// Submodules have no size
+	size, _ := strconv.ParseInt(fields[3], 10, 64)
+	return treeRecord{mode: fields[0], hash: fields[2], size: size, path: path}, true
+}
+
+func (t *Tree) readBlob(file TreeFile) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cmd == nil {
+		if err := t.startCatFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := fmt.Fprintln(t.stdin, file.Hash); err != nil {
+		return nil, err
+	}
+
+	header, err := t.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	// "<object> blob <size>" or "<object> missing"
+	fields := strings.Fields(header)
+	if len(fields) != 3 || fields[1] != "blob" {
+		return nil, fmt.Errorf("git cat-file: cannot read %s (%s): %s", file.Path, file.Hash, strings.TrimSpace(header))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: invalid header %q", header)
+	}
+
+	// The content is followed by a newline
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(t.stdout, content); err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	return content[:size], nil
+}
+
+func (t *Tree) startCatFile() error {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = t.workTree
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	t.cmd = cmd
+	t.stdin = stdin
+	t.stdout = bufio.NewReaderSize(stdout, 64*1024)
+	return nil
+}
+
+// Close stops the git cat-file process, if one was started.
+func (t *Tree) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cmd == nil {
+		return nil
+	}
+	t.stdin.Close()
+	err := t.cmd.Wait()
+	t.cmd = nil
+	return err
+}
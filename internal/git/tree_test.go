@@ -0,0 +1,96 @@
+package git
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestOpenTree(t *testing.T) {
+	repoDir := initTestRepo(t)
+	runGit(t, repoDir, "commit", "-q", "-m", "initial")
+	runGit(t, repoDir, "tag", "v1")
+
+	// Later changes must not show up when reading v1
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package changed\n"), 0644); err != nil {
+		t.Fatalf("Failed to write main.go: %v", err)
+	}
+	runGit(t, repoDir, "commit", "-q", "-am", "change")
+	if err := os.Symlink("main.go", filepath.Join(repoDir, "link.go")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	runGit(t, repoDir, "add", "link.go")
+	runGit(t, repoDir, "commit", "-q", "-m", "link")
+
+	repo, err := FindRepository(repoDir)
+	if err != nil {
+		t.Fatalf("FindRepository failed: %v", err)
+	}
+
+	tree, err := repo.OpenTree(repoDir, "v1")
+	if err != nil {
+		t.Fatalf("OpenTree failed: %v", err)
+	}
+	defer tree.Close()
+
+	content, err := fs.ReadFile(tree, "main.go")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(content) != "package main\n" {
+		t.Errorf("Expected content from v1, got %q", content)
+	}
+	if len(tree.Files) != 6 {
+		t.Errorf("Expected 6 files, got %d", len(tree.Files))
+	}
+
+	if _, err := fs.ReadFile(tree, "scratch.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist for a file outside the revision, got %v", err)
+	}
+
+	head, err := repo.OpenTree(filepath.Join(repoDir, "src"), "HEAD")
+	if err != nil {
+		t.Fatalf("OpenTree failed: %v", err)
+	}
+	defer head.Close()
+
+	var paths []string
+	err = fs.WalkDir(head, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir failed: %v", err)
+	}
+	expected := []string{"nested/deep/file.ts", "nested/deep/other.ts", "utils.go"}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+
+	if _, err := fs.Stat(tree, "link.go"); err == nil {
+		t.Error("Expected link.go to be missing from v1")
+	}
+	headRoot, err := repo.OpenTree(repoDir, "HEAD")
+	if err != nil {
+		t.Fatalf("OpenTree failed: %v", err)
+	}
+	defer headRoot.Close()
+	if info, err := fs.Stat(headRoot, "link.go"); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("Expected link.go to be a symlink, got %v", err)
+	}
+	if _, err := fs.ReadFile(headRoot, "link.go"); err == nil {
+		t.Error("Expected reading a symlink to fail")
+	}
+
+	if _, err := repo.OpenTree(repoDir, "does-not-exist"); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+}
@@ -0,0 +1,223 @@
+// Package history reads the commands from shell history files, so what is
+// typed in a terminal can be counted like source code.
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatAuto Format = "auto"
+	FormatBash Format = "bash"
+	FormatZsh  Format = "zsh"
+	FormatFish Format = "fish"
+)
+
+// Formats are the names ParseFormat accepts.
+var Formats = []Format{FormatAuto, FormatBash, FormatZsh, FormatFish}
+
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == name {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown history format %q", name)
+}
+
+// Options configures how history files are read.
+type Options struct {
+	// Format of the history files; FormatAuto or empty detects it per file
+	Format Format
+	// Dedupe keeps only the first occurrence of each command
+	Dedupe bool
+}
+
+// zsh extended history, ": <start>:<elapsed>;<command>"
+var zshExtended = regexp.MustCompile(`^: \d+:\d+;`)
+
+// bash history timestamps written with HISTTIMEFORMAT, "#<start>"
+var bashTimestamp = regexp.MustCompile(`^#\d+$`)
+
+// ReadFile returns the commands of the history file at path.
+func ReadFile(path string, opts Options) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format := opts.Format
+	if format == "" || format == FormatAuto {
+		format = Detect(path, data)
+	}
+
+	commands := Parse(data, format)
+	if opts.Dedupe {
+		commands = Dedupe(commands)
+	}
+	return commands, nil
+}
+
+// Detect guesses the format of a history file from its first entry, then
+// from its name. Plain histories without timestamps are read as bash.
+func Detect(path string, data []byte) Format {
+	for line := range bytes.Lines(data) {
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("- cmd: ")) {
+			return FormatFish
+		}
+		if zshExtended.Match(line) {
+			return FormatZsh
+		}
+		break
+	}
+
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.Contains(name, "fish"):
+		return FormatFish
+	case strings.Contains(name, "zsh"), strings.Contains(name, "zhistory"):
+		return FormatZsh
+	}
+	return FormatBash
+}
+
+// Parse returns the commands of a history file in the given format, in the
+// order they were run.
+func Parse(data []byte, format Format) []string {
+	switch format {
+	case FormatZsh:
+		return ParseZsh(data)
+	case FormatFish:
+		return ParseFish(data)
+	}
+	return ParseBash(data)
+}
+
+// ParseBash reads one command per line, skipping timestamp comments.
+func ParseBash(data []byte) []string {
+	var commands []string
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" || bashTimestamp.MatchString(line) {
+			continue
+		}
+		commands = append(commands, line)
+	}
+	return commands
+}
+
+// ParseZsh reads plain and extended zsh history. Multi-line commands are
+// stored with a backslash before each newline, and non-ASCII bytes are
+// "metafied" by zsh.
+func ParseZsh(data []byte) []string {
+	var commands []string
+	var current strings.Builder
+	continued := false
+	for line := range strings.Lines(unmetafy(data)) {
+		line = strings.TrimRight(line, "\r\n")
+		if !continued {
+			line = zshExtended.ReplaceAllString(line, "")
+		}
+
+		var more bool
+		line, more = strings.CutSuffix(line, "\\")
+		current.WriteString(line)
+		if more {
+			current.WriteByte('\n')
+			continued = true
+			continue
+		}
+
+		if current.Len() > 0 {
+			commands = append(commands, current.String())
+		}
+		current.Reset()
+		continued = false
+	}
+	if current.Len() > 0 {
+		commands = append(commands, strings.TrimSuffix(current.String(), "\n"))
+	}
+	return commands
+}
+
+// zshMeta marks a byte that zsh stored XORed with 32.
+const zshMeta = 0x83
+
+func unmetafy(data []byte) string {
+	if bytes.IndexByte(data, zshMeta) < 0 {
+		return string(data)
+	}
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == zshMeta && i+1 < len(data) {
+			i++
+			out = append(out, data[i]^32)
+			continue
+		}
+		out = append(out, data[i])
+	}
+	return string(out)
+}
+
+// ParseFish reads the "- cmd:" entries of fish's YAML-like history. Fish
+// escapes newlines as \n and backslashes as \\.
+func ParseFish(data []byte) []string {
+	var commands []string
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimRight(line, "\r\n")
+		command, ok := strings.CutPrefix(line, "- cmd: ")
+		if !ok {
+			continue
+		}
+		commands = append(commands, unescapeFish(command))
+	}
+	return commands
+}
+
+func unescapeFish(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Dedupe keeps the first occurrence of each command.
+func Dedupe(commands []string) []string {
+	seen := make(map[string]bool, len(commands))
+	unique := commands[:0:0]
+	for _, command := range commands {
+		if seen[command] {
+			continue
+		}
+		seen[command] = true
+		unique = append(unique, command)
+	}
+	return unique
+}
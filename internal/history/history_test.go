@@ -0,0 +1,102 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		data     string
+		expected []string
+	}{
+		{
+			name:     "bash",
+			format:   FormatBash,
+			data:     "ls -la\n#1690000000\ngit status\r\n\necho '#1'\n",
+			expected: []string{"ls -la", "git status", "echo '#1'"},
+		},
+		{
+			name:     "zsh extended",
+			format:   FormatZsh,
+			data:     ": 1690000000:0;git status\n: 1690000005:12;for f in *; do\\\necho $f\\\ndone\n: 1690000009:0;ls\n",
+			expected: []string{"git status", "for f in *; do\necho $f\ndone", "ls"},
+		},
+		{
+			name:     "zsh plain",
+			format:   FormatZsh,
+			data:     "cd ~\nls\n",
+			expected: []string{"cd ~", "ls"},
+		},
+		{
+			name:     "zsh metafied",
+			format:   FormatZsh,
+			data:     ": 1690000000:0;echo \xc3\x83\xa4\n",
+			expected: []string{"echo Ä"},
+		},
+		{
+			name:     "fish",
+			format:   FormatFish,
+			data:     "- cmd: git status\n  when: 1690000000\n- cmd: echo a\\\\nb\\nc\n  when: 1690000001\n  paths:\n    - cmd.txt\n",
+			expected: []string{"git status", "echo a\\nb\nc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := Parse([]byte(tt.data), tt.format)
+			if !slices.Equal(commands, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, commands)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		path     string
+		data     string
+		expected Format
+	}{
+		{"history", "- cmd: ls\n  when: 1\n", FormatFish},
+		{"history", "\n: 1690000000:0;ls\n", FormatZsh},
+		{".zsh_history", "ls\n", FormatZsh},
+		{"fish_history", "", FormatFish},
+		{".bash_history", "ls\n", FormatBash},
+		{"history.txt", "ls\n", FormatBash},
+	}
+
+	for _, tt := range tests {
+		if format := Detect(tt.path, []byte(tt.data)); format != tt.expected {
+			t.Errorf("Detect(%q, %q) = %s, want %s", tt.path, tt.data, format, tt.expected)
+		}
+	}
+}
+
+func TestReadFileDedupe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zsh_history")
+	data := ": 1:0;ls\n: 2:0;git status\n: 3:0;ls\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	commands, err := ReadFile(path, Options{Dedupe: true})
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if expected := []string{"ls", "git status"}; !slices.Equal(commands, expected) {
+		t.Errorf("Expected %q, got %q", expected, commands)
+	}
+
+	commands, err = ReadFile(path, Options{Format: FormatBash})
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if len(commands) != 3 || commands[0] != ": 1:0;ls" {
+		t.Errorf("Expected the forced bash format to keep the zsh prefixes, got %q", commands)
+	}
+}
@@ -0,0 +1,220 @@
+package ignorer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ogdakke/symbolista/internal/logger"
+)
+
+// LinguistAttributes are the .gitattributes attributes that GitHub linguist
+// uses to leave files out of language statistics. Files with any of them set
+// are ignored.
+var LinguistAttributes = []string{"linguist-vendored", "linguist-generated", "linguist-documentation"}
+
+// DefaultVendorDirs are directory names that usually hold dependencies or
+// build output rather than code written by hand.
+var DefaultVendorDirs = []string{"vendor", "node_modules", "third_party", "dist"}
+
+// AttributesMatcher ignores files that .gitattributes files mark with one of
+// the LinguistAttributes. As in git, a deeper .gitattributes takes precedence
+// over a shallower one, the last matching line in a file wins, and patterns
+// only match files, not the directories they are in.
+type AttributesMatcher struct {
+	pathRoot
+	readFile func(path string) ([]byte, error)
+	// Compiled .gitattributes files keyed like GitignoreMatcher.matchers,
+	// with one rule set per attribute in LinguistAttributes. Unsetting an
+	// attribute is stored as a negated rule.
+	matchers map[string][]*ruleSet
+	mu       sync.Mutex
+}
+
+func newAttributesMatcher(basePath string, readFile func(string) ([]byte, error)) *AttributesMatcher {
+	return &AttributesMatcher{
+		pathRoot: newPathRoot(basePath),
+		readFile: readFile,
+		matchers: make(map[string][]*ruleSet),
+	}
+}
+
+// Decide reports whether a linguist attribute is set for the file at path.
+// The .gitattributes files above path are read on first use, so this works
+// for file lists as well as directory walks.
+func (m *AttributesMatcher) Decide(path string) Decision {
+	if m == nil {
+		return Included
+	}
+
+	rel, ok := m.relativePath(path)
+	if !ok || rel == "" {
+		return Included
+	}
+	segments := strings.Split(rel, "/")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ruleSetsByDepth := make([][]*ruleSet, len(segments))
+	dirKey := rel
+	for depth := len(segments) - 1; depth >= 0; depth-- {
+		dirKey = parentOf(dirKey)
+		ruleSetsByDepth[depth] = m.load(dirKey)
+	}
+
+	for i := range LinguistAttributes {
+		for depth := len(segments) - 1; depth >= 0; depth-- {
+			ruleSets := ruleSetsByDepth[depth]
+			if ruleSets == nil || ruleSets[i] == nil {
+				continue
+			}
+			if rule := ruleSets[i].lastMatch(segments[depth:], false); rule != nil {
+				if !rule.negate {
+					return rule.decision()
+				}
+				break
+			}
+		}
+	}
+
+	return Included
+}
+
+// load returns the rule sets of the .gitattributes file in the directory,
+// reading it on first use. The caller must hold m.mu.
+func (m *AttributesMatcher) load(relDir string) []*ruleSet {
+	if ruleSets, ok := m.matchers[relDir]; ok {
+		return ruleSets
+	}
+
+	path := filepath.Join(m.basePath, filepath.FromSlash(relDir), ".gitattributes")
+	ruleSets, err := m.loadAttributesFile(path)
+	if err != nil {
+		logger.Debug("Cannot read .gitattributes", "path", path, "error", err)
+	}
+	m.matchers[relDir] = ruleSets
+	return ruleSets
+}
+
+func (m *AttributesMatcher) loadAttributesFile(path string) ([]*ruleSet, error) {
+	content, err := m.readFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ruleSets := make([]*ruleSet, len(LinguistAttributes))
+	found := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		pattern, attributes, ok := parseAttributesLine(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		for _, attribute := range attributes {
+			name, set := attributeState(attribute)
+			i := indexOf(LinguistAttributes, name)
+			if i < 0 {
+				continue
+			}
+
+			rule := parseIgnoreLine(pattern, path, lineNumber)
+			if rule == nil {
+				continue
+			}
+			rule.pattern = pattern + " " + attribute
+			rule.negate = !set
+			if ruleSets[i] == nil {
+				ruleSets[i] = newRuleSet()
+			}
+			ruleSets[i].add(rule)
+			found = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, nil
+	}
+	logger.Info("Linguist attributes loaded", "file", path)
+	return ruleSets, nil
+}
+
+// parseAttributesLine splits a .gitattributes line into its pattern and
+// attributes. Patterns may be quoted. Negative patterns are not allowed in
+// .gitattributes, so lines with one are skipped like git does.
+func parseAttributesLine(line string) (string, []string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil, false
+	}
+
+	var pattern, rest string
+	if strings.HasPrefix(line, `"`) {
+		end := closingQuote(line)
+		if end < 0 {
+			return "", nil, false
+		}
+		unquoted, err := strconv.Unquote(line[:end+1])
+		if err != nil {
+			return "", nil, false
+		}
+		pattern, rest = unquoted, line[end+1:]
+	} else {
+		pattern, rest, _ = strings.Cut(line, " ")
+		if i := strings.IndexAny(pattern, "\t"); i >= 0 {
+			pattern, rest = pattern[:i], pattern[i:]+" "+rest
+		}
+	}
+
+	if strings.HasPrefix(pattern, "!") || strings.HasPrefix(pattern, "[attr]") {
+		return "", nil, false
+	}
+	return pattern, strings.Fields(rest), true
+}
+
+func closingQuote(line string) int {
+	for i := 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// attributeState returns the name of an attribute and whether the line sets
+// it: "attr" and "attr=true" set it, while "-attr", "!attr" and "attr=false"
+// leave it unset.
+func attributeState(attribute string) (string, bool) {
+	if name, ok := strings.CutPrefix(attribute, "-"); ok {
+		return name, false
+	}
+	if name, ok := strings.CutPrefix(attribute, "!"); ok {
+		return name, false
+	}
+	name, value, hasValue := strings.Cut(attribute, "=")
+	return name, !hasValue || value != "false"
+}
+
+func indexOf(names []string, name string) int {
+	for i, candidate := range names {
+		if candidate == name {
+			return i
+		}
+	}
+	return -1
+}
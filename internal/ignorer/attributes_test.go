@@ -0,0 +1,172 @@
+package ignorer
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLinguistAttributes(t *testing.T) {
+	root := t.TempDir()
+	writeIgnoreFile(t, root, ".gitattributes", `# comment
+*.pb.go linguist-generated
+third/** linguist-vendored
+docs/*.md linguist-documentation
+docs/keep.md -linguist-documentation
+*.min.js linguist-vendored=true
+app.min.js linguist-vendored=false
+"quoted name.txt" linguist-generated
+!negated.txt linguist-generated
+*.txt text eol=lf
+`)
+	writeIgnoreFile(t, filepath.Join(root, "api"), ".gitattributes", "*.pb.go !linguist-generated\n")
+
+	matcher, err := NewMatcherWithOptions(root, Options{LinguistAttributes: true})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		ignored bool
+		line    int
+	}{
+		{"service.pb.go", true, 2},
+		{"pkg/service.pb.go", true, 2},
+		{"api/service.pb.go", false, 0},
+		{"third/lib/x.c", true, 3},
+		{"docs/guide.md", true, 4},
+		{"docs/keep.md", false, 0},
+		{"docs/deep/guide.md", false, 0},
+		{"lib.min.js", true, 6},
+		{"app.min.js", false, 0},
+		{"quoted name.txt", true, 8},
+		{"negated.txt", false, 0},
+		{"main.go", false, 0},
+	}
+
+	for _, tt := range tests {
+		decision := matcher.Decide(filepath.Join(root, tt.path), false)
+		if decision.Ignored != tt.ignored {
+			t.Errorf("%s: ignored = %v, want %v (%s)", tt.path, decision.Ignored, tt.ignored, decision)
+			continue
+		}
+		if tt.ignored && (decision.Rule != RuleAttributes || decision.Line != tt.line) {
+			t.Errorf("%s: decided by %s line %d, want %s line %d", tt.path, decision.Rule, decision.Line, RuleAttributes, tt.line)
+		}
+	}
+
+	if decision := matcher.Decide(filepath.Join(root, "third"), true); decision.Ignored {
+		t.Errorf("attributes should not ignore directories, got %s", decision)
+	}
+}
+
+func TestLinguistAttributesFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitattributes": {Data: []byte("gen/* linguist-generated\n")},
+		"gen/a.go":       {Data: []byte("package gen\n")},
+	}
+
+	matcher, err := NewMatcherWithOptions("archive.zip", Options{FS: fsys, LinguistAttributes: true})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if !matcher.ShouldIgnore(filepath.Join("archive.zip", "gen", "a.go")) {
+		t.Error("gen/a.go should be ignored by .gitattributes read from the FS")
+	}
+}
+
+func TestVendorDirs(t *testing.T) {
+	root := t.TempDir()
+	matcher, err := NewMatcherWithOptions(root, Options{VendorDirs: []string{"vendor", "node_modules/"}})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+		parent  string
+	}{
+		{"vendor", true, true, ""},
+		{"src/node_modules", true, true, ""},
+		{"vendor/lib/a.go", false, true, "vendor"},
+		{"web/node_modules/x/index.js", false, true, "web/node_modules"},
+		{"vendor", false, false, ""},
+		{"vendors/a.go", false, false, ""},
+		{"dist/app.js", false, false, ""},
+	}
+
+	for _, tt := range tests {
+		decision := matcher.Decide(filepath.Join(root, tt.path), tt.isDir)
+		if decision.Ignored != tt.ignored {
+			t.Errorf("%s: ignored = %v, want %v (%s)", tt.path, decision.Ignored, tt.ignored, decision)
+			continue
+		}
+		if !tt.ignored {
+			continue
+		}
+		if decision.Rule != RuleVendorDir {
+			t.Errorf("%s: rule = %s, want %s", tt.path, decision.Rule, RuleVendorDir)
+		}
+		wantParent := ""
+		if tt.parent != "" {
+			wantParent = filepath.Join(root, tt.parent)
+		}
+		if decision.Parent != wantParent {
+			t.Errorf("%s: parent = %q, want %q", tt.path, decision.Parent, wantParent)
+		}
+	}
+}
+
+func TestDotfileDirs(t *testing.T) {
+	root := t.TempDir()
+	matcher, err := NewMatcherWithOptions(root, Options{})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+		parent  string
+	}{
+		{".github", true, true, ""},
+		{".env", false, true, ""},
+		// Tracked and listed files are not reached through their directory
+		{".github/ci.yml", false, true, ".github"},
+		{"src/.config/deep/app.json", false, true, "src/.config"},
+		{"src/main.go", false, false, ""},
+	}
+
+	for _, tt := range tests {
+		decision := matcher.Decide(filepath.Join(root, tt.path), tt.isDir)
+		if decision.Ignored != tt.ignored {
+			t.Errorf("%s: ignored = %v, want %v (%s)", tt.path, decision.Ignored, tt.ignored, decision)
+			continue
+		}
+		if !tt.ignored {
+			continue
+		}
+		if decision.Rule != RuleDotfile {
+			t.Errorf("%s: rule = %s, want %s", tt.path, decision.Rule, RuleDotfile)
+		}
+		wantParent := ""
+		if tt.parent != "" {
+			wantParent = filepath.Join(root, tt.parent)
+		}
+		if decision.Parent != wantParent {
+			t.Errorf("%s: parent = %q, want %q", tt.path, decision.Parent, wantParent)
+		}
+	}
+
+	included, err := NewMatcherWithOptions(root, Options{IncludeDotfiles: true})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if decision := included.Decide(filepath.Join(root, ".github", "ci.yml"), false); decision.Ignored {
+		t.Errorf("Expected .github/ci.yml with --include-dotfiles to be included, got %s", decision)
+	}
+}
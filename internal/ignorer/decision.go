@@ -0,0 +1,102 @@
+package ignorer
+
+import (
+	"fmt"
+	"io/fs"
+	"unicode/utf8"
+)
+
+// Rule identifies which check decided whether a path is analyzed.
+type Rule string
+
+const (
+	RuleNone               Rule = ""
+	RuleGitignore          Rule = "gitignore"
+	RuleIgnoreFile         Rule = "ignore-file"
+	RuleAttributes         Rule = "gitattributes"
+	RuleVendorDir          Rule = "vendor-dir"
+	RuleDotfile            Rule = "dotfile"
+	RuleExtension          Rule = "extension"
+	RuleExtensionAllowlist Rule = "extension-allowlist"
+	RuleNamePattern        Rule = "name-pattern"
+	RuleSpecialFile        Rule = "special-file"
+	RuleNonUTF8            Rule = "non-utf8"
+	RuleSizeLimit          Rule = "size-limit"
+)
+
+// Decision is the outcome of matching a path, together with the rule that
+// made the decision.
+type Decision struct {
+	Ignored bool
+	Rule    Rule
+	// Pattern is the gitignore pattern, extension or glob that matched
+	Pattern string
+	// Source is the ignore file the pattern came from, if any
+	Source string
+	// Line is the 1-based line number of the pattern in Source
+	Line int
+	// Parent is set when the path was skipped because a parent directory was ignored
+	Parent string
+}
+
+// Included is the decision for paths that no rule ignores.
+var Included = Decision{}
+
+func (d Decision) String() string {
+	if !d.Ignored {
+		return "included"
+	}
+
+	if d.Parent != "" {
+		parentDecision := d
+		parentDecision.Parent = ""
+		return fmt.Sprintf("%s via parent directory %s", parentDecision, d.Parent)
+	}
+
+	switch d.Rule {
+	case RuleGitignore, RuleIgnoreFile, RuleAttributes:
+		return fmt.Sprintf("ignored by %s:%d (%s)", d.Source, d.Line, d.Pattern)
+	case RuleVendorDir:
+		return fmt.Sprintf("ignored by vendor directory rule (%s, use --vendor-dir to change)", d.Pattern)
+	case RuleDotfile:
+		return "ignored by dotfile rule (use --include-dotfiles)"
+	case RuleExtension:
+		return fmt.Sprintf("ignored by extension rule (%s)", d.Pattern)
+	case RuleExtensionAllowlist:
+		return fmt.Sprintf("ignored by extension allowlist (%s is not allowed)", displayExtension(d.Pattern))
+	case RuleNamePattern:
+		return fmt.Sprintf("ignored by file name rule (%s)", d.Pattern)
+	case RuleSpecialFile:
+		return fmt.Sprintf("ignored by special file check (%s)", d.Pattern)
+	case RuleNonUTF8:
+		return "ignored by non-UTF-8 content check"
+	case RuleSizeLimit:
+		return fmt.Sprintf("ignored by size limit (%s)", d.Pattern)
+	default:
+		return "ignored"
+	}
+}
+
+func displayExtension(ext string) string {
+	if ext == "" {
+		return "no extension"
+	}
+	return ext
+}
+
+// DecideMode ignores anything that is not a regular file, such as symlinks,
+// sockets and devices.
+func DecideMode(mode fs.FileMode) Decision {
+	if mode&fs.ModeType != 0 {
+		return Decision{Ignored: true, Rule: RuleSpecialFile, Pattern: mode.Type().String()}
+	}
+	return Included
+}
+
+// DecideContent ignores content that is not valid UTF-8.
+func DecideContent(content []byte) Decision {
+	if !utf8.Valid(content) {
+		return Decision{Ignored: true, Rule: RuleNonUTF8}
+	}
+	return Included
+}
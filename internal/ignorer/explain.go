@@ -0,0 +1,77 @@
+package ignorer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Explain reports the decision a directory walk starting at root would make
+// for path. Gitignore files are loaded for every directory between root and
+// path, the same way the walk loads them.
+func (m *Matcher) Explain(root, path string) (Decision, error) {
+	if filepath.IsAbs(root) != filepath.IsAbs(path) {
+		var err error
+		if root, err = filepath.Abs(root); err != nil {
+			return Included, err
+		}
+		if path, err = filepath.Abs(path); err != nil {
+			return Included, err
+		}
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return Included, err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return Included, fmt.Errorf("%s is not inside %s", path, root)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return Included, err
+	}
+
+	if err := m.LoadGitignoreForDirectory(root); err != nil {
+		return Included, err
+	}
+	if rel == "." {
+		return Included, nil
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	dir := root
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		if err := m.LoadGitignoreForDirectory(dir); err != nil {
+			return Included, err
+		}
+		if decision := m.Decide(dir, true); decision.Ignored {
+			decision.Parent = dir
+			return decision, nil
+		}
+	}
+
+	path = filepath.Join(root, rel)
+	if info.IsDir() {
+		return m.Decide(path, true), nil
+	}
+
+	if decision := DecideMode(info.Mode()); decision.Ignored {
+		return decision, nil
+	}
+	if decision := m.Decide(path, false); decision.Ignored {
+		return decision, nil
+	}
+	if decision := m.DecideSize(info.Size()); decision.Ignored {
+		return decision, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Included, err
+	}
+	return DecideContent(content), nil
+}
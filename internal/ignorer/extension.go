@@ -2,17 +2,23 @@ package ignorer
 
 import (
 	"path/filepath"
+	"strings"
 
 	"github.com/ogdakke/symbolista/internal/logger"
 )
 
 type ExtensionIgnorer struct {
 	ignoredExtensions map[string]bool
+	// When non-empty, only files with one of these extensions are kept
+	allowedExtensions map[string]bool
+	// Glob patterns matched against the file name, for rules like *_test.go
+	ignoredPatterns []string
 }
 
 func NewExtensionIgnorer() *ExtensionIgnorer {
 	ignorer := &ExtensionIgnorer{
 		ignoredExtensions: make(map[string]bool),
+		allowedExtensions: make(map[string]bool),
 	}
 
 	ignorer.addDefaultIgnoredExtensions()
@@ -30,20 +36,65 @@ func (e *ExtensionIgnorer) addDefaultIgnoredExtensions() {
 }
 
 func (e *ExtensionIgnorer) ShouldIgnore(path string) bool {
-	ext := filepath.Ext(path)
+	return e.Decide(path).Ignored
+}
+
+func (e *ExtensionIgnorer) Decide(path string) Decision {
+	ext := strings.ToLower(filepath.Ext(path))
 	if ext != "" && e.ignoredExtensions[ext] {
 		logger.Trace("Ignoring file by extension", "path", path, "extension", ext)
-		return true
+		return Decision{Ignored: true, Rule: RuleExtension, Pattern: ext}
 	}
-	return false
+
+	if len(e.allowedExtensions) > 0 && !e.allowedExtensions[ext] {
+		logger.Trace("Ignoring file not in extension allowlist", "path", path, "extension", ext)
+		return Decision{Ignored: true, Rule: RuleExtensionAllowlist, Pattern: ext}
+	}
+
+	filename := filepath.Base(path)
+	for _, pattern := range e.ignoredPatterns {
+		if matched, _ := filepath.Match(pattern, filename); matched {
+			logger.Trace("Ignoring file by name pattern", "path", path, "pattern", pattern)
+			return Decision{Ignored: true, Rule: RuleNamePattern, Pattern: pattern}
+		}
+	}
+
+	return Included
 }
 
 func (e *ExtensionIgnorer) AddExtension(ext string) {
-	if ext != "" {
+	if ext = normalizeExtension(ext); ext != "" {
 		e.ignoredExtensions[ext] = true
 	}
 }
 
 func (e *ExtensionIgnorer) RemoveExtension(ext string) {
-	delete(e.ignoredExtensions, ext)
+	delete(e.ignoredExtensions, normalizeExtension(ext))
+}
+
+// AllowExtension adds ext to the allowlist. Once the allowlist has any
+// entries, files with other extensions are ignored.
+func (e *ExtensionIgnorer) AllowExtension(ext string) {
+	if ext = normalizeExtension(ext); ext != "" {
+		e.allowedExtensions[ext] = true
+	}
+}
+
+// AddPattern ignores files whose name matches the glob pattern.
+func (e *ExtensionIgnorer) AddPattern(pattern string) {
+	if pattern != "" {
+		e.ignoredPatterns = append(e.ignoredPatterns, pattern)
+	}
+}
+
+// normalizeExtension accepts "go", ".go" or ".GO" and returns ".go".
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext == "" || ext == "." {
+		return ""
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
 }
@@ -2,25 +2,76 @@ package ignorer
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ogdakke/symbolista/internal/logger"
 )
 
-type GitignoreMatcher struct {
-	patterns []string
+// DefaultIgnoreFileNames are the ignore files read in every directory, from
+// lowest to highest precedence. This is the order ripgrep and fd use.
+var DefaultIgnoreFileNames = []string{".gitignore", ".ignore", ".rgignore"}
+
+// pathRoot turns paths below basePath into slash separated relative paths.
+type pathRoot struct {
 	basePath string
-	// Stack of gitignore matchers for nested directories
-	matchers map[string][]string
+	// basePath cleaned, with a trailing separator, to cut relative paths cheaply
+	basePrefix string
+}
+
+func newPathRoot(basePath string) pathRoot {
+	return pathRoot{
+		basePath:   basePath,
+		basePrefix: strings.TrimSuffix(filepath.Clean(basePath), string(filepath.Separator)) + string(filepath.Separator),
+	}
+}
+
+type GitignoreMatcher struct {
+	pathRoot
+	// names of the ignore files to read, from lowest to highest precedence
+	names []string
+	// readFile reads ignore files, from disk unless they come from elsewhere
+	readFile func(path string) ([]byte, error)
+	// Compiled rules for nested directories, keyed by the slash separated
+	// directory path relative to basePath ("" for basePath itself). Each
+	// entry has one rule set per name, nil when the file does not exist.
+	matchers map[string][]*ruleSet
+	// Decisions for directories that have already been matched. A directory
+	// is only matched once, and files below it reuse the cached decision.
+	dirCache map[string]Decision
+	mu       sync.Mutex
 }
 
 func NewGitignoreMatcher(basePath string) (*GitignoreMatcher, error) {
+	return NewIgnoreFileMatcher(basePath, DefaultIgnoreFileNames)
+}
+
+// NewIgnoreFileMatcher reads the given ignore files, such as .gitignore or
+// .dockerignore, where later names take precedence over earlier ones.
+func NewIgnoreFileMatcher(basePath string, names []string) (*GitignoreMatcher, error) {
+	return newIgnoreFileMatcher(basePath, names, os.ReadFile)
+}
+
+func newIgnoreFileMatcher(basePath string, names []string, readFile func(string) ([]byte, error)) (*GitignoreMatcher, error) {
+	for _, name := range names {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("invalid ignore file name %q", name)
+		}
+	}
+
 	matcher := &GitignoreMatcher{
-		basePath: basePath,
-		matchers: make(map[string][]string),
+		pathRoot: newPathRoot(basePath),
+		names:    names,
+		readFile: readFile,
+		matchers: make(map[string][]*ruleSet),
+		dirCache: make(map[string]Decision),
 	}
 
 	if err := matcher.loadGitignoreForDir(basePath); err != nil {
@@ -31,148 +82,218 @@ func NewGitignoreMatcher(basePath string) (*GitignoreMatcher, error) {
 }
 
 func (m *GitignoreMatcher) loadGitignoreForDir(dirPath string) error {
-	gitignorePath := filepath.Join(dirPath, ".gitignore")
-	if _, err := os.Stat(gitignorePath); os.IsNotExist(err) {
-		logger.Debug("No .gitignore found", "path", gitignorePath)
+	relDir, ok := m.relativePath(dirPath)
+	if !ok {
+		return nil
+	}
+
+	m.mu.Lock()
+	_, loaded := m.matchers[relDir]
+	m.mu.Unlock()
+	if loaded {
 		return nil
 	}
 
-	logger.Debug("Loading .gitignore", "path", gitignorePath)
-	file, err := os.Open(gitignorePath)
+	ruleSets := make([]*ruleSet, len(m.names))
+	for i, name := range m.names {
+		// .dockerignore only has meaning at the root of the build context
+		if isDockerignore(name) && relDir != "" {
+			continue
+		}
+
+		rules, err := m.loadIgnoreFile(filepath.Join(dirPath, name), isDockerignore(name))
+		if err != nil {
+			return err
+		}
+		if rules != nil {
+			ruleSets[i] = rules
+			logger.Info("Ignore patterns loaded", "patterns", rules.len(), "file", name, "dir", dirPath)
+		}
+	}
+
+	m.mu.Lock()
+	m.matchers[relDir] = ruleSets
+	m.mu.Unlock()
+
+	return nil
+}
+
+// loadIgnoreFile compiles one ignore file, returning nil if it does not
+// exist or has no patterns.
+func (m *GitignoreMatcher) loadIgnoreFile(path string, docker bool) (*ruleSet, error) {
+	content, err := m.readFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.Trace("No ignore file found", "path", path)
+		return nil, nil
+	}
 	if err != nil {
-		logger.Error("Cannot open .gitignore", "path", gitignorePath, "error", err)
-		return err
+		logger.Error("Cannot read ignore file", "path", path, "error", err)
+		return nil, err
+	}
+
+	logger.Debug("Loading ignore file", "path", path)
+	rules := newRuleSet()
+	rules.docker = docker
+
+	parse := parseIgnoreLine
+	if docker {
+		parse = parseDockerignoreLine
 	}
-	defer file.Close()
 
-	var patterns []string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNumber := 0
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" && !strings.HasPrefix(line, "#") {
-			patterns = append(patterns, line)
-			logger.Trace("Added gitignore pattern", "pattern", line, "dir", dirPath)
+		lineNumber++
+		if rule := parse(scanner.Text(), path, lineNumber); rule != nil {
+			rules.add(rule)
+			logger.Trace("Added ignore pattern", "pattern", rule.pattern, "file", path, "line", lineNumber)
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
 
-	if len(patterns) > 0 {
-		m.matchers[dirPath] = patterns
-		if dirPath == m.basePath {
-			m.patterns = patterns
-		}
-		logger.Info("Gitignore patterns loaded", "patterns", len(patterns), "dir", dirPath)
+	if rules.len() == 0 {
+		return nil, nil
 	}
+	return rules, nil
+}
 
-	return scanner.Err()
+func isDockerignore(name string) bool {
+	return strings.HasSuffix(name, ".dockerignore")
 }
 
 func (m *GitignoreMatcher) LoadGitignoreForDirectory(dirPath string) error {
 	return m.loadGitignoreForDir(dirPath)
 }
 
+// relativePath returns path relative to basePath with forward slashes, or
+// false if path is outside of basePath.
+func (r pathRoot) relativePath(path string) (string, bool) {
+	// Paths from the walk are already clean and below basePath, which makes
+	// the common case a substring instead of a full filepath.Rel
+	if rel, ok := strings.CutPrefix(path, r.basePrefix); ok && rel != "" {
+		return filepath.ToSlash(rel), true
+	}
+	if r.basePrefix == "."+string(filepath.Separator) && filepath.IsLocal(path) && path != "." {
+		return filepath.ToSlash(path), true
+	}
+
+	rel, err := filepath.Rel(r.basePath, path)
+	if err != nil {
+		logger.Debug("Cannot get relative path", "base", r.basePath, "path", path, "error", err)
+		return "", false
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel), true
+}
+
 func (m *GitignoreMatcher) ShouldIgnore(path string) bool {
+	return m.Decide(path, false).Ignored
+}
+
+// Decide reports whether path is ignored and, if so, which ignore file line
+// caused it. Ignore files for the directories above path must have been
+// loaded before, which the directory walk takes care of.
+func (m *GitignoreMatcher) Decide(path string, isDir bool) Decision {
 	if m == nil {
-		return false
+		return Included
 	}
 
 	start := time.Now()
 
-	currentDir := filepath.Dir(path)
-	for {
-		relDir, err := filepath.Rel(m.basePath, currentDir)
-		if err != nil || strings.HasPrefix(relDir, "..") {
-			break
-		}
+	rel, ok := m.relativePath(path)
+	if !ok || rel == "" {
+		return Included
+	}
 
-		if patterns, exists := m.matchers[currentDir]; exists {
-			// Get relative path from this directory's perspective
-			relPath, err := filepath.Rel(currentDir, path)
-			if err != nil {
-				logger.Debug("Cannot get relative path", "base", currentDir, "path", path, "error", err)
-			} else {
-				relPath = filepath.ToSlash(relPath)
-				for _, pattern := range patterns {
-					if m.matchesPattern(relPath, pattern) {
-						duration := time.Since(start)
-						logger.Trace("File matched gitignore pattern", "path", relPath, "pattern", pattern, "gitignore_dir", currentDir, "match_duration", duration)
-						return true
-					}
-				}
-			}
-		}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-		parentDir := filepath.Dir(currentDir)
-		if parentDir == currentDir || parentDir == "." {
-			break
+	parentRel := parentOf(rel)
+	if parent := m.decideDir(parentRel); parent.Ignored {
+		if parent.Parent == "" {
+			parent.Parent = filepath.Join(m.basePath, filepath.FromSlash(parentRel))
 		}
-		currentDir = parentDir
+		return parent
+	}
+
+	var decision Decision
+	if isDir {
+		decision = m.decideDir(rel)
+	} else {
+		decision = m.decideOwn(rel, false)
 	}
 
 	duration := time.Since(start)
-	if duration > time.Microsecond*100 {
+	if decision.Ignored {
+		logger.Trace("File matched ignore pattern", "path", rel, "pattern", decision.Pattern, "source", decision.Source, "match_duration", duration)
+	} else if duration > time.Microsecond*100 {
 		logger.Trace("Gitignore pattern matching completed", "path", path, "duration", duration)
 	}
 
-	return false
+	return decision
 }
 
-func (m *GitignoreMatcher) matchesPattern(relPath, pattern string) bool {
-	pattern = filepath.ToSlash(pattern)
-
-	if strings.HasSuffix(pattern, "/") {
-		dirPattern := strings.TrimSuffix(pattern, "/")
-		if relPath == dirPattern || strings.HasPrefix(relPath, dirPattern+"/") {
-			return true
-		}
-
-		parts := strings.Split(relPath, "/")
-		for i, part := range parts {
-			if part == dirPattern {
-				if i == len(parts)-1 || len(parts) > i+1 {
-					return true
-				}
-			}
-		}
+// decideDir returns the cached decision for a directory, computing it from
+// its parents first. The caller must hold m.mu.
+func (m *GitignoreMatcher) decideDir(rel string) Decision {
+	if rel == "" {
+		return Included
+	}
+	if decision, ok := m.dirCache[rel]; ok {
+		return decision
 	}
 
-	// Handle patterns starting with /
-	if after, ok := strings.CutPrefix(pattern, "/"); ok {
-		pattern = after
-		// Root-anchored pattern - match from root only
-		if matched, _ := filepath.Match(pattern, relPath); matched {
-			return true
-		}
-		// For directory traversal, also check if any parent directory matches
-		parts := strings.Split(relPath, "/")
-		for i := range parts {
-			partialPath := strings.Join(parts[:i+1], "/")
-			if matched, _ := filepath.Match(pattern, partialPath); matched {
-				return true
-			}
+	parentRel := parentOf(rel)
+	decision := m.decideDir(parentRel)
+	if decision.Ignored {
+		if decision.Parent == "" {
+			decision.Parent = filepath.Join(m.basePath, filepath.FromSlash(parentRel))
 		}
 	} else {
-		if matched, _ := filepath.Match(pattern, relPath); matched {
-			return true
-		}
+		decision = m.decideOwn(rel, true)
+	}
 
-		if matched, _ := filepath.Match(pattern, filepath.Base(relPath)); matched {
-			return true
-		}
+	m.dirCache[rel] = decision
+	return decision
+}
+
+// decideOwn matches the path itself against every loaded ignore file above
+// it. Like ripgrep, a higher precedence file name wins over lower ones in any
+// directory, then deeper files take precedence over shallower ones, and
+// within a file the last matching pattern wins. The caller must hold m.mu.
+func (m *GitignoreMatcher) decideOwn(rel string, isDir bool) Decision {
+	segments := strings.Split(rel, "/")
+
+	for i := len(m.names) - 1; i >= 0; i-- {
+		dirKey := rel
+		for depth := len(segments) - 1; depth >= 0; depth-- {
+			// Slice the directory key out of rel instead of joining segments
+			dirKey = parentOf(dirKey)
 
-		parts := strings.Split(relPath, "/")
-		for _, part := range parts {
-			if matched, _ := filepath.Match(pattern, part); matched {
-				return true
+			ruleSets, ok := m.matchers[dirKey]
+			if !ok || ruleSets[i] == nil {
+				continue
 			}
-		}
 
-		for i := range parts {
-			partialPath := strings.Join(parts[i:], "/")
-			if matched, _ := filepath.Match(pattern, partialPath); matched {
-				return true
+			if rule := ruleSets[i].match(segments[depth:], isDir); rule != nil {
+				return rule.decision()
 			}
 		}
 	}
 
-	return false
+	return Included
+}
+
+func parentOf(rel string) string {
+	if i := strings.LastIndexByte(rel, '/'); i >= 0 {
+		return rel[:i]
+	}
+	return ""
 }
@@ -0,0 +1,99 @@
+package ignorer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// syntheticTree writes a .gitignore with patternCount patterns of mixed
+// kinds and returns the matcher together with paths to match, as the walk
+// would visit them.
+func syntheticTree(b *testing.B, patternCount, dirCount, filesPerDir int) (*GitignoreMatcher, []string, []string) {
+	b.Helper()
+
+	root := b.TempDir()
+
+	var patterns strings.Builder
+	for i := 0; i < patternCount; i++ {
+		switch i % 6 {
+		case 0:
+			fmt.Fprintf(&patterns, "*.ext%d\n", i)
+		case 1:
+			fmt.Fprintf(&patterns, "name%d\n", i)
+		case 2:
+			fmt.Fprintf(&patterns, "/dir%d/sub/*.txt\n", i)
+		case 3:
+			fmt.Fprintf(&patterns, "cache%d/\n", i)
+		case 4:
+			fmt.Fprintf(&patterns, "**/gen%d/*.go\n", i)
+		case 5:
+			fmt.Fprintf(&patterns, "tmp%d-*.log\n", i)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(patterns.String()), 0644); err != nil {
+		b.Fatalf("Failed to write .gitignore: %v", err)
+	}
+
+	matcher, err := NewGitignoreMatcher(root)
+	if err != nil {
+		b.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	var dirs, files []string
+	for d := 0; d < dirCount; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("pkg%d", d%10), fmt.Sprintf("module%d", d), "src", "internal", "deep")
+		dirs = append(dirs, dir)
+		for f := 0; f < filesPerDir; f++ {
+			files = append(files, filepath.Join(dir, fmt.Sprintf("file%d.go", f)))
+		}
+	}
+
+	return matcher, dirs, files
+}
+
+func BenchmarkGitignoreDecide(b *testing.B) {
+	for _, patternCount := range []int{10, 1000, 5000} {
+		b.Run(fmt.Sprintf("patterns=%d", patternCount), func(b *testing.B) {
+			matcher, _, files := syntheticTree(b, patternCount, 200, 20)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				matcher.Decide(files[i%len(files)], false)
+			}
+		})
+	}
+}
+
+func BenchmarkGitignoreWalk(b *testing.B) {
+	matcher, dirs, files := syntheticTree(b, 5000, 200, 20)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// A fresh cache per iteration, the same as a new run
+		matcher.dirCache = make(map[string]Decision)
+		for _, dir := range dirs {
+			matcher.Decide(dir, true)
+		}
+		for _, file := range files {
+			matcher.Decide(file, false)
+		}
+	}
+}
+
+func BenchmarkLoadGitignore(b *testing.B) {
+	matcher, _, _ := syntheticTree(b, 5000, 0, 0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matcher.matchers = make(map[string][]*ruleSet)
+		if err := matcher.LoadGitignoreForDirectory(matcher.basePath); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package ignorer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeGitignore(t *testing.T, dir, content string) {
+	t.Helper()
+	writeIgnoreFile(t, dir, ".gitignore", content)
+}
+
+func writeIgnoreFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+func TestGitignoreMatcherSemantics(t *testing.T) {
+	root := t.TempDir()
+	writeGitignore(t, root, `# comment
+*.log
+!keep.log
+build/
+/rooted.txt
+docs/*.md
+**/generated/*.go
+vendor/**
+tmp?.txt
+[abc]x.js
+\#hash
+`)
+	writeGitignore(t, filepath.Join(root, "sub"), "*.tmp\n!important.log\n")
+
+	matcher, err := NewGitignoreMatcher(root)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if err := matcher.LoadGitignoreForDirectory(filepath.Join(root, "sub")); err != nil {
+		t.Fatalf("Failed to load nested .gitignore: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+		line    int
+	}{
+		{"app.log", false, true, 2},
+		{"deep/nested/app.log", false, true, 2},
+		{"keep.log", false, false, 0},
+		{"build", true, true, 4},
+		{"build", false, false, 0},
+		{"src/build", true, true, 4},
+		{"src/build/out.js", false, true, 4},
+		{"rooted.txt", false, true, 5},
+		{"src/rooted.txt", false, false, 0},
+		{"docs/readme.md", false, true, 6},
+		{"src/docs/readme.md", false, false, 0},
+		{"generated/a.go", false, true, 7},
+		{"x/y/generated/a.go", false, true, 7},
+		{"vendor", true, false, 0},
+		{"vendor/lib/a.go", false, true, 8},
+		{"tmp1.txt", false, true, 9},
+		{"tmp12.txt", false, false, 0},
+		{"ax.js", false, true, 10},
+		{"dx.js", false, false, 0},
+		{"#hash", false, true, 11},
+		{"sub/file.tmp", false, true, 1},
+		{"file.tmp", false, false, 0},
+		{"sub/important.log", false, false, 0},
+		{"sub/other.log", false, true, 2},
+		{"main.go", false, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			decision := matcher.Decide(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir)
+			if decision.Ignored != tt.ignored {
+				t.Fatalf("Expected ignored=%v for %s, got %v", tt.ignored, tt.path, decision)
+			}
+			if tt.ignored && decision.Line != tt.line {
+				t.Errorf("Expected line %d for %s, got %v", tt.line, tt.path, decision)
+			}
+		})
+	}
+}
+
+func TestIgnoreFilePrecedence(t *testing.T) {
+	root := t.TempDir()
+	writeGitignore(t, root, "*.log\n")
+	writeIgnoreFile(t, root, ".ignore", "!keep.log\n*.tmp\n")
+	writeIgnoreFile(t, root, ".rgignore", "!keep.tmp\n")
+	writeGitignore(t, filepath.Join(root, "sub"), "!*.tmp\n")
+
+	matcher, err := NewGitignoreMatcher(root)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if err := matcher.LoadGitignoreForDirectory(filepath.Join(root, "sub")); err != nil {
+		t.Fatalf("Failed to load nested ignore files: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		ignored bool
+		rule    Rule
+	}{
+		{"app.log", true, RuleGitignore},
+		{"keep.log", false, RuleNone},
+		{"a.tmp", true, RuleIgnoreFile},
+		{"keep.tmp", false, RuleNone},
+		// .ignore in a parent wins over .gitignore in a subdirectory
+		{"sub/a.tmp", true, RuleIgnoreFile},
+	}
+
+	for _, tt := range tests {
+		decision := matcher.Decide(filepath.Join(root, filepath.FromSlash(tt.path)), false)
+		if decision.Ignored != tt.ignored || decision.Rule != tt.rule {
+			t.Errorf("Expected ignored=%v rule=%q for %s, got %+v", tt.ignored, tt.rule, tt.path, decision)
+		}
+	}
+}
+
+func TestIgnoreFileNames(t *testing.T) {
+	root := t.TempDir()
+	writeGitignore(t, root, "*.log\n")
+	writeIgnoreFile(t, root, ".customignore", "*.tmp\n")
+
+	matcher, err := NewIgnoreFileMatcher(root, []string{".customignore"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if matcher.ShouldIgnore(filepath.Join(root, "app.log")) {
+		t.Error("Expected .gitignore to be skipped when not configured")
+	}
+	if !matcher.ShouldIgnore(filepath.Join(root, "a.tmp")) {
+		t.Error("Expected .customignore patterns to apply")
+	}
+
+	if _, err := NewIgnoreFileMatcher(root, []string{"sub/.gitignore"}); err == nil {
+		t.Error("Expected an error for an ignore file name with a separator")
+	}
+}
+
+func TestDockerignoreSemantics(t *testing.T) {
+	root := t.TempDir()
+	writeIgnoreFile(t, root, ".dockerignore", `# comment
+node_modules
+/build/
+*.md
+!README.md
+docs
+!docs/keep.txt
+**/*.bak
+./tmp/*
+`)
+	// Nested .dockerignore files are not read by docker
+	writeIgnoreFile(t, filepath.Join(root, "src"), ".dockerignore", "*.go\n")
+
+	matcher, err := NewIgnoreFileMatcher(root, []string{".dockerignore"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if err := matcher.LoadGitignoreForDirectory(filepath.Join(root, "src")); err != nil {
+		t.Fatalf("Failed to load nested ignore files: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"node_modules", true, true},
+		{"node_modules/a/index.js", false, true},
+		// Patterns are relative to the root, not to any directory
+		{"src/node_modules/index.js", false, false},
+		{"build", false, true},
+		{"build/out.js", false, true},
+		{"CHANGELOG.md", false, true},
+		{"src/notes.md", false, false},
+		{"README.md", false, false},
+		// Exceptions may re-include files below an excluded directory
+		{"docs", true, false},
+		{"docs/guide.txt", false, true},
+		{"docs/keep.txt", false, false},
+		{"a.bak", false, true},
+		{"src/deep/a.bak", false, true},
+		{"tmp/x.txt", false, true},
+		{"src/main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			decision := matcher.Decide(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir)
+			if decision.Ignored != tt.ignored {
+				t.Errorf("Expected ignored=%v for %s, got %v", tt.ignored, tt.path, decision)
+			}
+		})
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*", "anything", true},
+		{"*.go", "main.go", true},
+		{"*.go", "main.gox", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"?.txt", "a.txt", true},
+		{"?.txt", "ab.txt", false},
+		{"[a-c]1", "b1", true},
+		{"[!a-c]1", "b1", false},
+		{"[^a-c]1", "d1", true},
+		{`\*.txt`, "*.txt", true},
+		{`\*.txt`, "a.txt", false},
+		{"[unclosed", "[unclosed", true},
+		{"e*", "école", false},
+		{"?cole", "école", true},
+	}
+
+	for _, tt := range tests {
+		if got := compileGlob(tt.pattern).match(tt.name); got != tt.match {
+			t.Errorf("compileGlob(%q).match(%q) = %v, want %v", tt.pattern, tt.name, got, tt.match)
+		}
+	}
+}
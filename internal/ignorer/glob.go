@@ -0,0 +1,207 @@
+package ignorer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+type globTokenKind int
+
+const (
+	globLiteral globTokenKind = iota
+	globAnyChar
+	globStar
+	globClass
+)
+
+type globToken struct {
+	kind    globTokenKind
+	literal string
+	class   *charClass
+}
+
+type charClass struct {
+	negated bool
+	ranges  []runeRange
+}
+
+type runeRange struct {
+	lo, hi rune
+}
+
+func (c *charClass) matches(r rune) bool {
+	found := false
+	for _, rr := range c.ranges {
+		if rr.lo <= r && r <= rr.hi {
+			found = true
+			break
+		}
+	}
+	return found != c.negated
+}
+
+// compiledGlob matches a single path segment against a gitignore glob. The
+// pattern is parsed once into tokens so matching does not re-parse it.
+type compiledGlob struct {
+	tokens []globToken
+}
+
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, `*?[\`)
+}
+
+func compileGlob(pattern string) compiledGlob {
+	var tokens []globToken
+	var literal strings.Builder
+
+	flushLiteral := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, globToken{kind: globLiteral, literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				literal.WriteByte(pattern[i])
+			}
+		case '?':
+			flushLiteral()
+			tokens = append(tokens, globToken{kind: globAnyChar})
+		case '*':
+			flushLiteral()
+			// Consecutive stars behave like one within a segment
+			if len(tokens) == 0 || tokens[len(tokens)-1].kind != globStar {
+				tokens = append(tokens, globToken{kind: globStar})
+			}
+		case '[':
+			class, next, ok := parseCharClass(pattern, i)
+			if !ok {
+				literal.WriteByte(c)
+				continue
+			}
+			flushLiteral()
+			tokens = append(tokens, globToken{kind: globClass, class: class})
+			i = next
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	flushLiteral()
+
+	return compiledGlob{tokens: tokens}
+}
+
+// parseCharClass parses a bracket expression starting at pattern[start] and
+// returns the index of the closing bracket.
+func parseCharClass(pattern string, start int) (*charClass, int, bool) {
+	class := &charClass{}
+	i := start + 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		class.negated = true
+		i++
+	}
+
+	first := true
+	for i < len(pattern) {
+		if pattern[i] == ']' && !first {
+			return class, i, true
+		}
+		first = false
+
+		lo, size := readClassRune(pattern, i)
+		if size == 0 {
+			return nil, 0, false
+		}
+		i += size
+
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, size = readClassRune(pattern, i+1)
+			if size == 0 {
+				return nil, 0, false
+			}
+			i += 1 + size
+		}
+		class.ranges = append(class.ranges, runeRange{lo: lo, hi: hi})
+	}
+
+	return nil, 0, false
+}
+
+func readClassRune(pattern string, i int) (rune, int) {
+	if pattern[i] == '\\' {
+		if i+1 >= len(pattern) {
+			return 0, 0
+		}
+		r, size := utf8.DecodeRuneInString(pattern[i+1:])
+		return r, size + 1
+	}
+	r, size := utf8.DecodeRuneInString(pattern[i:])
+	return r, size
+}
+
+// literalPrefix returns the literal text the glob must start with, if any.
+func (g compiledGlob) literalPrefix() string {
+	if len(g.tokens) > 0 && g.tokens[0].kind == globLiteral {
+		return g.tokens[0].literal
+	}
+	return ""
+}
+
+func (g compiledGlob) match(s string) bool {
+	return matchTokens(g.tokens, s)
+}
+
+func matchTokens(tokens []globToken, s string) bool {
+	// Position to resume from when a later token fails after a star
+	starToken, starPos := -1, 0
+	ti, si := 0, 0
+
+	for {
+		if ti < len(tokens) {
+			token := tokens[ti]
+			switch token.kind {
+			case globStar:
+				starToken, starPos = ti, si
+				ti++
+				continue
+			case globLiteral:
+				if strings.HasPrefix(s[si:], token.literal) {
+					ti++
+					si += len(token.literal)
+					continue
+				}
+			case globAnyChar:
+				if si < len(s) {
+					_, size := utf8.DecodeRuneInString(s[si:])
+					ti++
+					si += size
+					continue
+				}
+			case globClass:
+				if si < len(s) {
+					r, size := utf8.DecodeRuneInString(s[si:])
+					if token.class.matches(r) {
+						ti++
+						si += size
+						continue
+					}
+				}
+			}
+		} else if si == len(s) {
+			return true
+		}
+
+		if starToken < 0 || starPos >= len(s) {
+			return false
+		}
+		_, size := utf8.DecodeRuneInString(s[starPos:])
+		starPos += size
+		ti, si = starToken+1, starPos
+	}
+}
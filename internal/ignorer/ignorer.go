@@ -1,55 +1,259 @@
 package ignorer
 
 import (
+	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/ogdakke/symbolista/internal/logger"
 )
 
+// Options configures which rules a Matcher applies.
+type Options struct {
+	IncludeDotfiles bool
+	// DisableGitignore skips ignore file loading and matching, for file lists
+	// that were already filtered by git itself.
+	DisableGitignore bool
+	// IgnoreFileNames are the ignore files read in each directory, from lowest
+	// to highest precedence; empty means DefaultIgnoreFileNames
+	IgnoreFileNames []string
+	// FS holds the tree rooted at basePath when it is not a directory on
+	// disk, such as an archive or a git revision; nil reads the OS file system
+	FS fs.FS
+	// Extensions is an allowlist; when set, files with other extensions are ignored
+	Extensions []string
+	// ExcludeExtensions is a denylist applied on top of the defaults
+	ExcludeExtensions []string
+	// Presets are names of built-in rule sets, see Presets
+	Presets []string
+	// MaxFileSize skips files larger than this many bytes; 0 disables the limit
+	MaxFileSize int64
+	// VendorDirs are directory names skipped wherever they appear, see
+	// DefaultVendorDirs
+	VendorDirs []string
+	// LinguistAttributes skips files that .gitattributes marks as vendored,
+	// generated or documentation, see LinguistAttributes
+	LinguistAttributes bool
+}
+
 type Matcher struct {
-	gitignoreMatcher *GitignoreMatcher
-	extensionIgnorer *ExtensionIgnorer
-	includeDotfiles  bool
+	root              pathRoot
+	gitignoreMatcher  *GitignoreMatcher
+	attributesMatcher *AttributesMatcher
+	extensionIgnorer  *ExtensionIgnorer
+	vendorDirs        map[string]bool
+	includeDotfiles   bool
+	maxFileSize       int64
 }
 
 func NewMatcher(basePath string, includeDotfiles bool) (*Matcher, error) {
-	gitignoreMatcher, err := NewGitignoreMatcher(basePath)
-	if err != nil {
-		return nil, err
+	return NewMatcherWithOptions(basePath, Options{IncludeDotfiles: includeDotfiles})
+}
+
+// fsReader reads paths below basePath from fsys, which is rooted at basePath.
+func fsReader(fsys fs.FS, basePath string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		rel, err := filepath.Rel(basePath, path)
+		if err != nil || !filepath.IsLocal(rel) {
+			return nil, &fs.PathError{Op: "read", Path: path, Err: fs.ErrNotExist}
+		}
+		return fs.ReadFile(fsys, filepath.ToSlash(rel))
+	}
+}
+
+func NewMatcherWithOptions(basePath string, opts Options) (*Matcher, error) {
+	readFile := os.ReadFile
+	if opts.FS != nil {
+		readFile = fsReader(opts.FS, basePath)
+	}
+
+	var gitignoreMatcher *GitignoreMatcher
+	if !opts.DisableGitignore {
+		var err error
+		names := opts.IgnoreFileNames
+		if len(names) == 0 {
+			names = DefaultIgnoreFileNames
+		}
+		gitignoreMatcher, err = newIgnoreFileMatcher(basePath, names, readFile)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	extensionIgnorer := NewExtensionIgnorer()
 
+	for _, name := range opts.Presets {
+		preset, err := findPreset(name)
+		if err != nil {
+			return nil, err
+		}
+		preset.apply(extensionIgnorer)
+		logger.Debug("Applied preset", "preset", preset.Name)
+	}
+	for _, ext := range opts.Extensions {
+		extensionIgnorer.AllowExtension(ext)
+		// Explicitly allowed extensions win over the built-in defaults
+		extensionIgnorer.RemoveExtension(ext)
+	}
+	for _, ext := range opts.ExcludeExtensions {
+		extensionIgnorer.AddExtension(ext)
+	}
+
+	// Unlike ignore files, attributes still apply to file lists from git
+	var attributesMatcher *AttributesMatcher
+	if opts.LinguistAttributes {
+		attributesMatcher = newAttributesMatcher(basePath, readFile)
+	}
+
+	var vendorDirs map[string]bool
+	for _, name := range opts.VendorDirs {
+		name = strings.Trim(strings.TrimSpace(name), "/")
+		if name == "" {
+			continue
+		}
+		if vendorDirs == nil {
+			vendorDirs = make(map[string]bool)
+		}
+		vendorDirs[name] = true
+	}
+
 	matcher := &Matcher{
-		gitignoreMatcher: gitignoreMatcher,
-		extensionIgnorer: extensionIgnorer,
-		includeDotfiles:  includeDotfiles,
+		root:              newPathRoot(basePath),
+		gitignoreMatcher:  gitignoreMatcher,
+		attributesMatcher: attributesMatcher,
+		extensionIgnorer:  extensionIgnorer,
+		vendorDirs:        vendorDirs,
+		includeDotfiles:   opts.IncludeDotfiles,
+		maxFileSize:       opts.MaxFileSize,
 	}
 
 	return matcher, nil
 }
 
 func (m *Matcher) LoadGitignoreForDirectory(dirPath string) error {
+	if m.gitignoreMatcher == nil {
+		return nil
+	}
 	return m.gitignoreMatcher.LoadGitignoreForDirectory(dirPath)
 }
 
 func (m *Matcher) ShouldIgnore(path string) bool {
+	return m.Decide(path, false).Ignored
+}
+
+// ShouldIgnoreDir is like ShouldIgnore for directories. Extension and file
+// name rules only apply to files, so they are skipped here.
+func (m *Matcher) ShouldIgnoreDir(path string) bool {
+	return m.Decide(path, true).Ignored
+}
+
+// Decide reports whether path should be skipped and which rule decided it.
+func (m *Matcher) Decide(path string, isDir bool) Decision {
 	if m == nil {
-		return false
+		return Included
 	}
 
-	if m.extensionIgnorer.ShouldIgnore(path) {
-		return true
+	if !isDir {
+		if decision := m.extensionIgnorer.Decide(path); decision.Ignored {
+			return decision
+		}
 	}
 
 	if !m.includeDotfiles {
-		filename := filepath.Base(path)
-		if strings.HasPrefix(filename, ".") && filename != "." && filename != ".." {
-			logger.Trace("Ignoring dotfile", "path", path)
-			return true
+		if decision := m.decideDotfile(path); decision.Ignored {
+			return decision
 		}
 	}
 
-	return m.gitignoreMatcher.ShouldIgnore(path)
+	if decision := m.decideVendorDir(path, isDir); decision.Ignored {
+		return decision
+	}
+
+	if decision := m.gitignoreMatcher.Decide(path, isDir); decision.Ignored || isDir {
+		return decision
+	}
+
+	return m.attributesMatcher.Decide(path)
+}
+
+// decideDotfile ignores dotfiles and dot-directories, and files below a
+// dot-directory for the inputs that are not walked directory by directory,
+// such as the git index and file lists.
+func (m *Matcher) decideDotfile(path string) Decision {
+	filename := filepath.Base(path)
+	if isDotfile(filename) {
+		logger.Trace("Ignoring dotfile", "path", path)
+		return Decision{Ignored: true, Rule: RuleDotfile, Pattern: filename}
+	}
+
+	rel, ok := m.root.relativePath(path)
+	if !ok || rel == "" {
+		return Included
+	}
+	segments := strings.Split(rel, "/")
+	segments = segments[:len(segments)-1]
+	for i, segment := range segments {
+		if !isDotfile(segment) {
+			continue
+		}
+		logger.Trace("Ignoring file in dot-directory", "path", path, "dir", segment)
+		return Decision{
+			Ignored: true,
+			Rule:    RuleDotfile,
+			Pattern: segment,
+			Parent:  filepath.Join(m.root.basePath, filepath.FromSlash(strings.Join(segments[:i+1], "/"))),
+		}
+	}
+	return Included
+}
+
+func isDotfile(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+// decideVendorDir ignores vendor directories, and files below one for the
+// inputs that are not walked directory by directory, such as file lists.
+func (m *Matcher) decideVendorDir(path string, isDir bool) Decision {
+	if len(m.vendorDirs) == 0 {
+		return Included
+	}
+
+	rel, ok := m.root.relativePath(path)
+	if !ok || rel == "" {
+		return Included
+	}
+	segments := strings.Split(rel, "/")
+	if !isDir {
+		segments = segments[:len(segments)-1]
+	}
+	for i, segment := range segments {
+		if !m.vendorDirs[segment] {
+			continue
+		}
+		logger.Trace("Ignoring vendor directory", "path", path, "dir", segment)
+		decision := Decision{Ignored: true, Rule: RuleVendorDir, Pattern: segment}
+		if !isDir || i < len(segments)-1 {
+			decision.Parent = filepath.Join(m.root.basePath, filepath.FromSlash(strings.Join(segments[:i+1], "/")))
+		}
+		return decision
+	}
+	return Included
+}
+
+// SizeLimit returns the maximum file size in bytes, or 0 when unlimited.
+func (m *Matcher) SizeLimit() int64 {
+	if m == nil {
+		return 0
+	}
+	return m.maxFileSize
+}
+
+// DecideSize ignores files larger than the configured size limit.
+func (m *Matcher) DecideSize(size int64) Decision {
+	if limit := m.SizeLimit(); limit > 0 && size > limit {
+		return Decision{Ignored: true, Rule: RuleSizeLimit, Pattern: fmt.Sprintf("%d bytes > %d bytes", size, limit)}
+	}
+	return Included
 }
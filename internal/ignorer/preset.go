@@ -0,0 +1,94 @@
+package ignorer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Preset is a named set of file rules that can be enabled with --preset.
+type Preset struct {
+	Name        string
+	Description string
+	// AllowExtensions restricts analysis to these extensions
+	AllowExtensions []string
+	// IgnoreExtensions skips files with these extensions
+	IgnoreExtensions []string
+	// IgnorePatterns skips files whose name matches one of these globs
+	IgnorePatterns []string
+}
+
+var presets = []Preset{
+	{
+		Name:        "code-only",
+		Description: "Only source code files of common programming languages",
+		AllowExtensions: []string{
+			".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh", ".cs", ".go", ".rs", ".zig",
+			".java", ".kt", ".kts", ".scala", ".groovy", ".swift", ".m", ".mm",
+			".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts", ".vue", ".svelte",
+			".py", ".rb", ".php", ".pl", ".lua", ".r", ".jl", ".dart",
+			".ex", ".exs", ".erl", ".hs", ".ml", ".mli", ".fs", ".fsx", ".clj", ".cljs", ".el", ".lisp",
+			".sh", ".bash", ".zsh", ".fish", ".ps1", ".sql",
+			".html", ".css", ".scss", ".sass", ".less",
+		},
+	},
+	{
+		Name:        "no-tests",
+		Description: "Skip test files such as *_test.go, *.spec.ts and test_*.py",
+		IgnorePatterns: []string{
+			"*_test.go",
+			"*.spec.ts", "*.spec.tsx", "*.spec.js", "*.spec.jsx",
+			"*.test.ts", "*.test.tsx", "*.test.js", "*.test.jsx",
+			"test_*.py", "*_test.py",
+			"*_spec.rb", "*_test.rb",
+			"*Test.java", "*Tests.java", "*Test.kt",
+			"*_test.exs",
+		},
+	},
+	{
+		Name:             "no-docs",
+		Description:      "Skip documentation files such as Markdown, reStructuredText and plain text",
+		IgnoreExtensions: []string{".md", ".markdown", ".mdx", ".rst", ".adoc", ".asciidoc", ".txt", ".org", ".rdoc"},
+		IgnorePatterns:   []string{"LICENSE*", "LICENCE*", "CHANGELOG*", "AUTHORS*", "CONTRIBUTORS*", "NOTICE*"},
+	},
+	{
+		Name:             "no-data",
+		Description:      "Skip data and fixture files such as JSON, CSV and YAML",
+		IgnoreExtensions: []string{".json", ".jsonl", ".ndjson", ".csv", ".tsv", ".yaml", ".yml", ".xml", ".lock"},
+	},
+}
+
+// Presets returns the built-in presets in the order they are documented.
+func Presets() []Preset {
+	return slices.Clone(presets)
+}
+
+// PresetNames returns the names of all built-in presets.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for _, preset := range presets {
+		names = append(names, preset.Name)
+	}
+	return names
+}
+
+func findPreset(name string) (Preset, error) {
+	for _, preset := range presets {
+		if preset.Name == name {
+			return preset, nil
+		}
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(PresetNames(), ", "))
+}
+
+func (p Preset) apply(e *ExtensionIgnorer) {
+	for _, ext := range p.AllowExtensions {
+		e.AllowExtension(ext)
+	}
+	for _, ext := range p.IgnoreExtensions {
+		e.AddExtension(ext)
+	}
+	for _, pattern := range p.IgnorePatterns {
+		e.AddPattern(pattern)
+	}
+}
@@ -0,0 +1,423 @@
+package ignorer
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segmentLiteral segmentKind = iota
+	segmentSuffix
+	segmentGlob
+	segmentDoubleStar
+)
+
+// segmentMatcher matches one path segment of a pattern.
+type segmentMatcher struct {
+	kind    segmentKind
+	literal string
+	glob    compiledGlob
+}
+
+func compileSegment(segment string) segmentMatcher {
+	if segment == "**" {
+		return segmentMatcher{kind: segmentDoubleStar}
+	}
+	if !hasGlobMeta(segment) {
+		return segmentMatcher{kind: segmentLiteral, literal: segment}
+	}
+	// "*.log" and friends are by far the most common globs
+	if rest, ok := strings.CutPrefix(segment, "*"); ok && rest != "" && !hasGlobMeta(rest) {
+		return segmentMatcher{kind: segmentSuffix, literal: rest}
+	}
+	return segmentMatcher{kind: segmentGlob, glob: compileGlob(segment)}
+}
+
+func (s segmentMatcher) match(segment string) bool {
+	switch s.kind {
+	case segmentLiteral:
+		return segment == s.literal
+	case segmentSuffix:
+		return strings.HasSuffix(segment, s.literal)
+	case segmentGlob:
+		return s.glob.match(segment)
+	default:
+		return true
+	}
+}
+
+// ignoreRule is a single compiled line of an ignore file.
+type ignoreRule struct {
+	pattern string
+	source  string
+	line    int
+	// index orders rules within their file; the last matching rule wins
+	index    int
+	negate   bool
+	dirOnly  bool
+	anchored bool
+	// name matches the last path segment of unanchored rules
+	name segmentMatcher
+	// segments match the remainder of the path after the literal trie prefix
+	segments []segmentMatcher
+}
+
+func (r *ignoreRule) decision() Decision {
+	if r.negate {
+		return Included
+	}
+	rule := RuleIgnoreFile
+	switch filepath.Base(r.source) {
+	case ".gitignore":
+		rule = RuleGitignore
+	case ".gitattributes":
+		rule = RuleAttributes
+	}
+	return Decision{
+		Ignored: true,
+		Rule:    rule,
+		Pattern: r.pattern,
+		Source:  r.source,
+		Line:    r.line,
+	}
+}
+
+// parseIgnoreLine compiles one line of a gitignore style file. It returns nil
+// for blank lines and comments.
+func parseIgnoreLine(line string, source string, lineNumber int) *ignoreRule {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	rule := &ignoreRule{pattern: line, source: source, line: lineNumber}
+
+	pattern := line
+	if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+		rule.negate = true
+		pattern = rest
+	} else if strings.HasPrefix(pattern, `\!`) || strings.HasPrefix(pattern, `\#`) {
+		pattern = pattern[1:]
+	}
+
+	if rest, ok := strings.CutSuffix(pattern, "/"); ok {
+		rule.dirOnly = true
+		pattern = rest
+	}
+
+	// A slash anywhere but the end anchors the pattern to the ignore file's directory
+	if strings.Contains(pattern, "/") {
+		rule.anchored = true
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+
+	// "**/name" matches name at any depth, the same as an unanchored pattern
+	if rest, ok := strings.CutPrefix(pattern, "**/"); ok && !strings.Contains(rest, "/") && rest != "**" {
+		rule.anchored = false
+		pattern = rest
+	}
+
+	if pattern == "" {
+		return nil
+	}
+
+	if rule.anchored {
+		for _, segment := range strings.Split(pattern, "/") {
+			rule.segments = append(rule.segments, compileSegment(segment))
+		}
+	} else {
+		rule.name = compileSegment(pattern)
+	}
+
+	return rule
+}
+
+// parseDockerignoreLine compiles one line of a .dockerignore file. Unlike
+// gitignore, every pattern is cleaned and matched from the root of the build
+// context, so "foo" only matches the top-level foo, and a trailing slash has
+// no special meaning.
+func parseDockerignoreLine(line string, source string, lineNumber int) *ignoreRule {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	rule := &ignoreRule{pattern: line, source: source, line: lineNumber, anchored: true}
+
+	pattern := line
+	if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+		rule.negate = true
+		pattern = strings.TrimSpace(rest)
+	}
+
+	pattern = strings.TrimPrefix(path.Clean(pattern), "/")
+	if pattern == "" || pattern == "." {
+		return nil
+	}
+
+	for _, segment := range strings.Split(pattern, "/") {
+		rule.segments = append(rule.segments, compileSegment(segment))
+	}
+
+	return rule
+}
+
+// matchSegments matches the remaining pattern segments against the remaining
+// path segments, expanding ** to any number of directories.
+func matchSegments(patterns []segmentMatcher, segments []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0].kind == segmentDoubleStar {
+			rest := patterns[1:]
+			if len(rest) == 0 {
+				// A trailing ** matches everything inside, but not the directory itself
+				return len(segments) > 0
+			}
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(rest, segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 || !patterns[0].match(segments[0]) {
+			return false
+		}
+		patterns = patterns[1:]
+		segments = segments[1:]
+	}
+	return len(segments) == 0
+}
+
+type trieNode struct {
+	children map[string]*trieNode
+	// rules whose literal prefix ends at this node
+	rules []*ignoreRule
+}
+
+// affixIndex finds rules by a literal prefix or suffix of a name with one
+// map lookup per distinct affix length, instead of testing every rule.
+type affixIndex struct {
+	rules   map[string][]*ignoreRule
+	lengths []int
+}
+
+func (a *affixIndex) add(affix string, rule *ignoreRule) {
+	if a.rules == nil {
+		a.rules = make(map[string][]*ignoreRule)
+	}
+	if !slices.Contains(a.lengths, len(affix)) {
+		a.lengths = append(a.lengths, len(affix))
+	}
+	a.rules[affix] = append(a.rules[affix], rule)
+}
+
+func (a *affixIndex) eachPrefix(name string, fn func(*ignoreRule)) {
+	for _, length := range a.lengths {
+		if len(name) >= length {
+			for _, rule := range a.rules[name[:length]] {
+				fn(rule)
+			}
+		}
+	}
+}
+
+func (a *affixIndex) eachSuffix(name string, fn func(*ignoreRule)) {
+	for _, length := range a.lengths {
+		if len(name) >= length {
+			for _, rule := range a.rules[name[len(name)-length:]] {
+				fn(rule)
+			}
+		}
+	}
+}
+
+// ruleSet is the compiled form of one ignore file. Unanchored rules are
+// indexed by the kind of name pattern, anchored rules live in a trie keyed
+// by their leading literal path segments, and "**/literal/..." rules are
+// keyed by their first literal segment.
+type ruleSet struct {
+	rules    []*ignoreRule
+	literals map[string][]*ignoreRule
+	suffixes affixIndex
+	// globs with a literal prefix, such as "tmp-*.log"
+	prefixes affixIndex
+	globs    []*ignoreRule
+	anchored *trieNode
+	floating map[string][]*ignoreRule
+	// docker rule sets match a path when a pattern matches it or any parent
+	docker bool
+	// segments of the "!" patterns in docker rule sets
+	exceptions [][]segmentMatcher
+}
+
+func newRuleSet() *ruleSet {
+	return &ruleSet{
+		literals: make(map[string][]*ignoreRule),
+		anchored: &trieNode{},
+		floating: make(map[string][]*ignoreRule),
+	}
+}
+
+func (rs *ruleSet) add(rule *ignoreRule) {
+	rule.index = len(rs.rules)
+	rs.rules = append(rs.rules, rule)
+	if rs.docker && rule.negate {
+		rs.exceptions = append(rs.exceptions, rule.segments)
+	}
+
+	if rule.anchored {
+		segments := rule.segments
+		if len(segments) > 2 && segments[0].kind == segmentDoubleStar && segments[1].kind == segmentLiteral {
+			rule.segments = segments[2:]
+			rs.floating[segments[1].literal] = append(rs.floating[segments[1].literal], rule)
+			return
+		}
+
+		node := rs.anchored
+		for len(segments) > 1 && segments[0].kind == segmentLiteral {
+			if node.children == nil {
+				node.children = make(map[string]*trieNode)
+			}
+			child, ok := node.children[segments[0].literal]
+			if !ok {
+				child = &trieNode{}
+				node.children[segments[0].literal] = child
+			}
+			node = child
+			segments = segments[1:]
+		}
+		rule.segments = segments
+		node.rules = append(node.rules, rule)
+		return
+	}
+
+	switch rule.name.kind {
+	case segmentLiteral:
+		rs.literals[rule.name.literal] = append(rs.literals[rule.name.literal], rule)
+	case segmentSuffix:
+		rs.suffixes.add(rule.name.literal, rule)
+	default:
+		if prefix := rule.name.glob.literalPrefix(); prefix != "" {
+			rs.prefixes.add(prefix, rule)
+		} else {
+			rs.globs = append(rs.globs, rule)
+		}
+	}
+}
+
+func (rs *ruleSet) len() int {
+	return len(rs.rules)
+}
+
+// match returns the rule that decides the path, or nil if none matches.
+func (rs *ruleSet) match(segments []string, isDir bool) *ignoreRule {
+	if !rs.docker {
+		return rs.lastMatch(segments, isDir)
+	}
+
+	// Docker can re-include files below an excluded directory, so such a
+	// directory must still be walked
+	if isDir && rs.mayReinclude(segments) {
+		return nil
+	}
+
+	var best *ignoreRule
+	for i := len(segments); i > 0; i-- {
+		if rule := rs.lastMatch(segments[:i], true); rule != nil && (best == nil || rule.index > best.index) {
+			best = rule
+		}
+	}
+	return best
+}
+
+// mayReinclude reports whether an exception pattern could match something
+// inside the directory.
+func (rs *ruleSet) mayReinclude(dir []string) bool {
+	for _, exception := range rs.exceptions {
+		if couldMatchBelow(exception, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func couldMatchBelow(patterns []segmentMatcher, dir []string) bool {
+	for _, segment := range dir {
+		if len(patterns) == 0 {
+			return false
+		}
+		if patterns[0].kind == segmentDoubleStar {
+			return true
+		}
+		if !patterns[0].match(segment) {
+			return false
+		}
+		patterns = patterns[1:]
+	}
+	return len(patterns) > 0
+}
+
+// lastMatch returns the last rule in the file that matches the path, given as
+// segments relative to the ignore file's directory.
+func (rs *ruleSet) lastMatch(segments []string, isDir bool) *ignoreRule {
+	var best *ignoreRule
+	better := func(rule *ignoreRule) bool {
+		return (!rule.dirOnly || isDir) && (best == nil || rule.index > best.index)
+	}
+	consider := func(rule *ignoreRule) {
+		if better(rule) {
+			best = rule
+		}
+	}
+
+	name := segments[len(segments)-1]
+	for _, rule := range rs.literals[name] {
+		consider(rule)
+	}
+	rs.suffixes.eachSuffix(name, consider)
+	rs.prefixes.eachPrefix(name, func(rule *ignoreRule) {
+		if better(rule) && rule.name.match(name) {
+			best = rule
+		}
+	})
+	for i := len(rs.globs) - 1; i >= 0; i-- {
+		rule := rs.globs[i]
+		if best != nil && rule.index < best.index {
+			break
+		}
+		if better(rule) && rule.name.match(name) {
+			best = rule
+			break
+		}
+	}
+
+	node := rs.anchored
+	for depth := 0; node != nil; depth++ {
+		for _, rule := range node.rules {
+			if better(rule) && matchSegments(rule.segments, segments[depth:]) {
+				best = rule
+			}
+		}
+		if depth >= len(segments) || node.children == nil {
+			break
+		}
+		node = node.children[segments[depth]]
+	}
+
+	if len(rs.floating) > 0 {
+		for depth, segment := range segments {
+			for _, rule := range rs.floating[segment] {
+				if better(rule) && matchSegments(rule.segments, segments[depth+1:]) {
+					best = rule
+				}
+			}
+		}
+	}
+
+	return best
+}
@@ -13,9 +13,9 @@ type TimingMatcher struct {
 	matchTime int64 // nanoseconds, atomic
 }
 
-func NewTimingMatcher(basePath string, includeDotfiles bool) (*TimingMatcher, error) {
+func NewTimingMatcher(basePath string, opts Options) (*TimingMatcher, error) {
 	loadStart := time.Now()
-	matcher, err := NewMatcher(basePath, includeDotfiles)
+	matcher, err := NewMatcherWithOptions(basePath, opts)
 	loadDuration := time.Since(loadStart)
 
 	if err != nil {
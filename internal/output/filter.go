@@ -0,0 +1,201 @@
+package output
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"unicode"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+// CharClass selects characters by kind, like the TUI's filters.
+type CharClass string
+
+const (
+	ClassAll     CharClass = ""
+	ClassLetters CharClass = "letters"
+	ClassDigits  CharClass = "digits"
+	// ClassSymbols are characters that are not letters, digits or whitespace
+	ClassSymbols    CharClass = "symbols"
+	ClassWhitespace CharClass = "whitespace"
+)
+
+var CharClasses = []CharClass{ClassLetters, ClassDigits, ClassSymbols, ClassWhitespace}
+
+func (c CharClass) contains(r rune) bool {
+	switch c {
+	case ClassLetters:
+		return unicode.IsLetter(r)
+	case ClassDigits:
+		return unicode.IsDigit(r)
+	case ClassSymbols:
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+	case ClassWhitespace:
+		return unicode.IsSpace(r)
+	}
+	return true
+}
+
+// CharOrder is the order characters are printed in.
+type CharOrder string
+
+const (
+	// OrderCount puts the most used characters first
+	OrderCount CharOrder = "count"
+	// OrderChar sorts characters alphabetically, with accented letters next
+	// to their base letter
+	OrderChar CharOrder = "char"
+	// OrderCodepoint sorts characters by their Unicode code point
+	OrderCodepoint CharOrder = "codepoint"
+)
+
+var CharOrders = []CharOrder{OrderCount, OrderChar, OrderCodepoint}
+
+// Filter narrows the results before they are written. Only and
+// ExcludeWhitespace apply to sequences as they do in the TUI: a sequence is
+// kept when any of its characters is in the class, and dropped when all of
+// them are whitespace. The other fields only apply to characters. The ndjson
+// file lines are narrowed by Only and ExcludeWhitespace, but not the limits.
+type Filter struct {
+	Only              CharClass
+	ExcludeWhitespace bool
+	// RelativePercentages recomputes the percentages from the characters
+	// and sequences left by Only and ExcludeWhitespace
+	RelativePercentages bool
+	MinCount            int
+	MinPercent          float64
+	// TopN keeps the most used characters; 0 keeps all of them
+	TopN int
+	Sort CharOrder
+}
+
+// Validate reports an error for unknown values and negative limits.
+func (f Filter) Validate() error {
+	if f.Only != ClassAll && !slices.Contains(CharClasses, f.Only) {
+		return fmt.Errorf("unknown character class %q (letters, digits, symbols, whitespace)", f.Only)
+	}
+	if f.Sort != "" && !slices.Contains(CharOrders, f.Sort) {
+		return fmt.Errorf("unknown sort order %q (count, char, codepoint)", f.Sort)
+	}
+	if f.MinCount < 0 || f.MinPercent < 0 || f.TopN < 0 {
+		return fmt.Errorf("character limits must not be negative")
+	}
+	return nil
+}
+
+// Apply returns the result with the characters and sequences the filter
+// keeps, and records the filter in its Config. The result's slices are not
+// modified.
+func (f Filter) Apply(result domain.AnalysisResult) domain.AnalysisResult {
+	// Results are already sorted by count
+	if f.Sort == OrderCount {
+		f.Sort = ""
+	}
+	if f == (Filter{}) {
+		return result
+	}
+
+	if result.Config != nil {
+		config := *result.Config
+		config.Filter = &domain.FilterConfig{
+			Only:                string(f.Only),
+			ExcludeWhitespace:   f.ExcludeWhitespace,
+			RelativePercentages: f.RelativePercentages,
+			MinCount:            f.MinCount,
+			MinPercent:          f.MinPercent,
+			TopNChars:           f.TopN,
+			Sort:                string(f.Sort),
+		}
+		result.Config = &config
+	}
+
+	result.CharCounts = f.chars(result.CharCounts)
+	result.SequenceCounts = domain.Filter(result.SequenceCounts, func(s domain.SequenceCount) bool {
+		return f.keepSequence(s.Sequence)
+	})
+	if f.RelativePercentages {
+		total := 0
+		for _, s := range result.SequenceCounts {
+			total += s.Count
+		}
+		for i := range result.SequenceCounts {
+			result.SequenceCounts[i].Percentage = percentOf(result.SequenceCounts[i].Count, total)
+		}
+	}
+
+	// Each source's characters are narrowed like the combined ones
+	result.Roots = slices.Clone(result.Roots)
+	for i := range result.Roots {
+		result.Roots[i].Characters = f.chars(result.Roots[i].Characters)
+	}
+	return result
+}
+
+// chars returns the characters the filter keeps, in its order. The counts
+// passed in are not modified.
+func (f Filter) chars(counts domain.CharCounts) domain.CharCounts {
+	chars := domain.Filter(counts, func(c domain.CharCount) bool {
+		return f.keepChar(c.Char)
+	})
+
+	if f.RelativePercentages {
+		total := 0
+		for _, c := range chars {
+			total += c.Count
+		}
+		for i := range chars {
+			chars[i].Percentage = percentOf(chars[i].Count, total)
+		}
+	}
+
+	chars = domain.Filter(chars, func(c domain.CharCount) bool {
+		return c.Count >= f.MinCount && c.Percentage >= f.MinPercent
+	})
+
+	// The most used characters are kept before they are sorted
+	if f.TopN > 0 && len(chars) > f.TopN {
+		chars = chars[:f.TopN]
+	}
+
+	switch f.Sort {
+	case OrderChar:
+		collator := collate.New(language.Und)
+		sort.SliceStable(chars, func(i, j int) bool {
+			return collator.CompareString(chars[i].Char, chars[j].Char) < 0
+		})
+	case OrderCodepoint:
+		sort.SliceStable(chars, func(i, j int) bool {
+			return chars[i].Char < chars[j].Char
+		})
+	}
+	return chars
+}
+
+func (f Filter) keepChar(char string) bool {
+	for _, r := range char {
+		if f.ExcludeWhitespace && unicode.IsSpace(r) || !f.Only.contains(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filter) keepSequence(sequence string) bool {
+	whitespace, inClass := true, false
+	for _, r := range sequence {
+		whitespace = whitespace && unicode.IsSpace(r)
+		inClass = inClass || f.Only.contains(r)
+	}
+	return inClass && !(f.ExcludeWhitespace && whitespace)
+}
+
+func percentOf(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total) * 100
+}
@@ -0,0 +1,217 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+func TestFilter(t *testing.T) {
+	result := domain.AnalysisResult{
+		CharCounts: domain.CharCounts{
+			{Char: " ", Count: 40, Percentage: 40},
+			{Char: "e", Count: 20, Percentage: 20},
+			{Char: "{", Count: 15, Percentage: 15},
+			{Char: "é", Count: 10, Percentage: 10},
+			{Char: "1", Count: 8, Percentage: 8},
+			{Char: "}", Count: 5, Percentage: 5},
+			{Char: "z", Count: 2, Percentage: 2},
+		},
+		SequenceCounts: domain.SequenceCounts{
+			{Sequence: "  ", Count: 30, Percentage: 60},
+			{Sequence: "e{", Count: 15, Percentage: 30},
+			{Sequence: "ze", Count: 5, Percentage: 10},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		filter      Filter
+		chars       []string
+		percentages []float64
+		// sequences are not checked when nil
+		sequences []string
+	}{
+		{"none", Filter{Sort: OrderCount}, []string{" ", "e", "{", "é", "1", "}", "z"}, nil, []string{"  ", "e{", "ze"}},
+		{"letters", Filter{Only: ClassLetters}, []string{"e", "é", "z"}, nil, []string{"e{", "ze"}},
+		{"digits", Filter{Only: ClassDigits}, []string{"1"}, nil, []string{}},
+		{"symbols", Filter{Only: ClassSymbols}, []string{"{", "}"}, nil, []string{"e{"}},
+		{"whitespace", Filter{Only: ClassWhitespace}, []string{" "}, nil, []string{"  "}},
+		{"exclude whitespace", Filter{ExcludeWhitespace: true}, []string{"e", "{", "é", "1", "}", "z"}, nil, []string{"e{", "ze"}},
+		{"relative", Filter{Only: ClassSymbols, RelativePercentages: true}, []string{"{", "}"}, []float64{75, 25}, []string{"e{"}},
+		{"min count", Filter{MinCount: 10}, []string{" ", "e", "{", "é"}, nil, []string{"  ", "e{", "ze"}},
+		{"min percent", Filter{MinPercent: 15}, []string{" ", "e", "{"}, []float64{40, 20, 15}, nil},
+		{"relative min percent", Filter{Only: ClassLetters, RelativePercentages: true, MinPercent: 50}, []string{"e"}, []float64{62.5}, nil},
+		{"top n", Filter{TopN: 2}, []string{" ", "e"}, nil, nil},
+		{"codepoint", Filter{Sort: OrderCodepoint, ExcludeWhitespace: true}, []string{"1", "e", "z", "{", "}", "é"}, nil, nil},
+		{"char", Filter{Sort: OrderChar, Only: ClassLetters}, []string{"e", "é", "z"}, nil, nil},
+		{"top n before sorting", Filter{Sort: OrderCodepoint, TopN: 3}, []string{" ", "e", "{"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := tt.filter.Apply(result)
+
+			var chars []string
+			var percentages []float64
+			for _, c := range filtered.CharCounts {
+				chars = append(chars, c.Char)
+				percentages = append(percentages, c.Percentage)
+			}
+			if !slices.Equal(chars, tt.chars) {
+				t.Errorf("Expected characters %q, got %q", tt.chars, chars)
+			}
+			if tt.percentages != nil && !slices.Equal(percentages, tt.percentages) {
+				t.Errorf("Expected percentages %v, got %v", tt.percentages, percentages)
+			}
+
+			if tt.sequences != nil {
+				var sequences []string
+				for _, s := range filtered.SequenceCounts {
+					sequences = append(sequences, s.Sequence)
+				}
+				if !slices.Equal(sequences, tt.sequences) {
+					t.Errorf("Expected sequences %q, got %q", tt.sequences, sequences)
+				}
+			}
+		})
+	}
+
+	if result.CharCounts[0].Char != " " || result.CharCounts[2].Percentage != 15 {
+		t.Error("Apply modified the result")
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		valid  bool
+	}{
+		{"default", Filter{Sort: OrderCount}, true},
+		{"all options", Filter{Only: ClassSymbols, ExcludeWhitespace: true, RelativePercentages: true, MinCount: 2, MinPercent: 0.5, TopN: 10, Sort: OrderChar}, true},
+		{"unknown class", Filter{Only: "vowels"}, false},
+		{"unknown order", Filter{Sort: "frequency"}, false},
+		{"negative top n", Filter{TopN: -1}, false},
+		{"negative min percent", Filter{MinPercent: -1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Expected valid=%v, got error %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestFilterRecordsConfig(t *testing.T) {
+	config := &domain.RunConfig{Workers: 2}
+	result := domain.AnalysisResult{Config: config}
+
+	if got := (Filter{Sort: OrderCount}).Apply(result); got.Config.Filter != nil {
+		t.Errorf("Expected no filter in the config of an unfiltered result, got %+v", got.Config.Filter)
+	}
+
+	filtered := Filter{Only: ClassSymbols, RelativePercentages: true, TopN: 5, Sort: OrderChar}.Apply(result)
+	expected := domain.FilterConfig{Only: "symbols", RelativePercentages: true, TopNChars: 5, Sort: "char"}
+	if filtered.Config.Filter == nil || *filtered.Config.Filter != expected {
+		t.Errorf("Expected filter config %+v, got %+v", expected, filtered.Config.Filter)
+	}
+	if filtered.Config.Workers != 2 || config.Filter != nil {
+		t.Errorf("Expected the filter on a copy of the config, got %+v and %+v", filtered.Config, config)
+	}
+}
+
+func TestFilterRoots(t *testing.T) {
+	roots := []domain.RootResult{
+		{Path: "a", Characters: domain.CharCounts{
+			{Char: "e", Count: 6, Percentage: 60},
+			{Char: ")", Count: 3, Percentage: 30},
+			{Char: "(", Count: 1, Percentage: 10},
+		}},
+		{Path: "b", Characters: domain.CharCounts{
+			{Char: "\n", Count: 5, Percentage: 50},
+			{Char: ";", Count: 3, Percentage: 30},
+			{Char: "{", Count: 1, Percentage: 10},
+			{Char: "}", Count: 1, Percentage: 10},
+		}},
+	}
+	result := domain.AnalysisResult{Roots: roots}
+
+	filtered := Filter{Only: ClassSymbols, RelativePercentages: true, TopN: 2, Sort: OrderCodepoint}.Apply(result)
+
+	expected := [][]domain.CharCount{
+		{{Char: "(", Count: 1, Percentage: 25}, {Char: ")", Count: 3, Percentage: 75}},
+		{{Char: ";", Count: 3, Percentage: 60}, {Char: "{", Count: 1, Percentage: 20}},
+	}
+	for i, root := range filtered.Roots {
+		if !slices.Equal(root.Characters, expected[i]) {
+			t.Errorf("Expected %v for %s, got %v", expected[i], root.Path, root.Characters)
+		}
+	}
+	if len(roots[1].Characters) != 4 || roots[0].Characters[1].Percentage != 30 {
+		t.Error("Apply modified the roots")
+	}
+}
+
+func TestFilterNDJSON(t *testing.T) {
+	result := domain.AnalysisResult{
+		CharCounts: domain.CharCounts{
+			{Char: "e", Count: 6, Percentage: 60},
+			{Char: ";", Count: 3, Percentage: 30},
+			{Char: " ", Count: 1, Percentage: 10},
+		},
+		SequenceCounts: domain.SequenceCounts{
+			{Sequence: "e;", Count: 2, Percentage: 50},
+			{Sequence: "ee", Count: 2, Percentage: 50},
+		},
+		Config: &domain.RunConfig{},
+	}
+
+	var stdout bytes.Buffer
+	outputter := NewOutputter(&stdout)
+	outputter.Filter = Filter{Only: ClassSymbols, MinCount: 5}
+	targets := []Target{{"ndjson", Stdout}}
+	if err := outputter.OpenStreams(targets); err != nil {
+		t.Fatalf("OpenStreams failed: %v", err)
+	}
+	outputter.OutputFile(domain.FileCounts{
+		Path:       "a",
+		Chars:      map[rune]int{'e': 6, ';': 3, ' ': 1},
+		Sequences:  map[string]int{"e;": 2, "ee": 2},
+		TotalChars: 10,
+	})
+	if err := outputter.OutputTargets(targets, result, true, "dir", true); err != nil {
+		t.Fatalf("OutputTargets failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a file and a summary line, got %q", stdout.String())
+	}
+
+	// The file line keeps the class but not the limits, so ; stays
+	expectedFile := `{"type":"file","path":"a","total_characters":10,"characters":{";":3},"sequences":{"e;":2}}`
+	if lines[0] != expectedFile {
+		t.Errorf("Expected file line %s, got %s", expectedFile, lines[0])
+	}
+
+	var summary domain.JSONOutput
+	if err := json.Unmarshal([]byte(lines[1]), &summary); err != nil {
+		t.Fatalf("Summary is not valid JSON: %v", err)
+	}
+	if len(summary.Result.Characters) != 0 {
+		t.Errorf("Expected --min-count to leave no characters in the summary, got %v", summary.Result.Characters)
+	}
+	if len(summary.Result.Sequences) != 1 || summary.Result.Sequences[0].Sequence != "e;" {
+		t.Errorf("Expected only the e; sequence in the summary, got %v", summary.Result.Sequences)
+	}
+	if summary.Metadata == nil || summary.Metadata.Config == nil || summary.Metadata.Config.Filter == nil || summary.Metadata.Config.Filter.Only != "symbols" {
+		t.Errorf("Expected the filter in the summary config, got %+v", summary.Metadata)
+	}
+}
@@ -0,0 +1,150 @@
+package output
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+//go:embed report.html.tmpl
+var reportTemplateText string
+
+var reportTemplate = template.Must(template.New("report").Parse(reportTemplateText))
+
+// reportRow is a character or sequence in the HTML report. The flags mirror
+// the TUI's filters so the page can apply them without parsing the text.
+type reportRow struct {
+	Display    string
+	Name       string
+	Count      int
+	Percentage string
+	Length     int
+	Alnum      bool
+	Symbol     bool
+	Whitespace bool
+}
+
+type reportMetadata struct {
+	Directory      string
+	FilesFound     int
+	FilesProcessed int
+	FilesIgnored   int
+	TotalChars     int
+	UniqueChars    int
+	Authors        *domain.AuthorStats
+	Duplicates     *domain.DuplicateStats
+	Timing         []reportTiming
+}
+
+type reportTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+type reportRoot struct {
+	domain.RootResult
+	Files int
+	Share string
+}
+
+type reportData struct {
+	Title           string
+	ShowPercentages bool
+	Characters      []reportRow
+	Sequences       []reportRow
+	Roots           []reportRoot
+	Metadata        *reportMetadata
+}
+
+// OutputHTML prints a self-contained HTML report with sortable tables, bar
+// charts and the TUI's filters. It loads nothing from the network.
+func (o *Outputter) OutputHTML(
+	result domain.AnalysisResult,
+	showPercentages bool,
+	directory string,
+	includeMetadata bool,
+) error {
+	page, err := renderHTML(result, showPercentages, directory, includeMetadata)
+	if err != nil {
+		return fmt.Errorf("could not render HTML: %w", err)
+	}
+	_, err = fmt.Fprint(o.w, page)
+	return err
+}
+
+func renderHTML(
+	result domain.AnalysisResult,
+	showPercentages bool,
+	directory string,
+	includeMetadata bool,
+) (string, error) {
+	data := reportData{
+		Title:           "Symbolista: " + directory,
+		ShowPercentages: showPercentages,
+	}
+	for _, c := range result.CharCounts {
+		data.Characters = append(data.Characters, newReportRow(c.Char, c.Count, c.Percentage))
+	}
+	for _, s := range result.SequenceCounts {
+		data.Sequences = append(data.Sequences, newReportRow(s.Sequence, s.Count, s.Percentage))
+	}
+	for _, root := range result.Roots {
+		data.Roots = append(data.Roots, reportRoot{
+			RootResult: root,
+			Files:      root.FilesFound - root.FilesIgnored,
+			Share:      fmt.Sprintf("%.2f%%", root.Share),
+		})
+	}
+
+	if includeMetadata {
+		data.Metadata = &reportMetadata{
+			Directory:      directory,
+			FilesFound:     result.FilesFound,
+			FilesProcessed: result.FilesFound - result.FilesIgnored,
+			FilesIgnored:   result.FilesIgnored,
+			TotalChars:     result.TotalChars,
+			UniqueChars:    result.UniqueChars,
+			Authors:        result.Authors,
+			Duplicates:     result.Duplicates,
+			Timing: []reportTiming{
+				{"Gitignore initialization", result.Timing.GitignoreDuration},
+				{"File traversal & counting", result.Timing.TraversalDuration},
+				{"Sorting results", result.Timing.SortingDuration},
+				{"Total", result.Timing.TotalDuration},
+			},
+		}
+	}
+
+	var page strings.Builder
+	if err := reportTemplate.Execute(&page, data); err != nil {
+		return "", err
+	}
+	return page.String(), nil
+}
+
+func newReportRow(text string, count int, percentage float64) reportRow {
+	row := reportRow{
+		Display:    whitespaceGlyphs(text),
+		Name:       charName(text),
+		Count:      count,
+		Percentage: fmt.Sprintf("%.2f%%", percentage),
+		Length:     len([]rune(text)),
+		Whitespace: text != "",
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			row.Alnum = true
+		} else {
+			row.Symbol = true
+		}
+		if !unicode.IsSpace(r) {
+			row.Whitespace = false
+		}
+	}
+	return row
+}
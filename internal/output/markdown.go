@@ -0,0 +1,133 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+// OutputMarkdown prints the result as GitHub-flavored Markdown tables, ready
+// to paste into an issue or a document.
+func (o *Outputter) OutputMarkdown(
+	result domain.AnalysisResult,
+	showPercentages bool,
+	directory string,
+	includeMetadata bool,
+) error {
+	_, err := fmt.Fprint(o.w, renderMarkdown(result, showPercentages, directory, includeMetadata))
+	return err
+}
+
+func renderMarkdown(
+	result domain.AnalysisResult,
+	showPercentages bool,
+	directory string,
+	includeMetadata bool,
+) string {
+	var b strings.Builder
+
+	b.WriteString("## Characters\n\n")
+	writeMarkdownHeader(&b, "Character", showPercentages)
+	formatChars(result.CharCounts, func(char string, count int, percentage float64) {
+		writeMarkdownRow(&b, markdownCode(char), count, percentage, showPercentages)
+	})
+
+	if len(result.SequenceCounts) > 0 {
+		b.WriteString("\n## Sequences (2-3 chars)\n\n")
+		writeMarkdownHeader(&b, "Sequence", showPercentages)
+		for _, seq := range formatSequences(result.SequenceCounts) {
+			writeMarkdownRow(&b, markdownCode(seq.Sequence), seq.Count, seq.Percentage, showPercentages)
+		}
+	}
+
+	if len(result.Roots) > 0 {
+		b.WriteString("\n## Sources (weighted)\n\n")
+		b.WriteString("| Source | Weight | Files | Characters | Share |\n")
+		b.WriteString("| :-- | --: | --: | --: | --: |\n")
+		for _, root := range result.Roots {
+			fmt.Fprintf(&b, "| %s | %g | %d | %d | %.2f%% |\n", markdownCode(root.Path), root.Weight, root.FilesFound-root.FilesIgnored, root.TotalChars, root.Share)
+		}
+	}
+
+	if includeMetadata {
+		b.WriteString("\n## Summary\n\n")
+		b.WriteString("| | |\n")
+		b.WriteString("| :-- | --: |\n")
+		fmt.Fprintf(&b, "| Directory | %s |\n", markdownCode(directory))
+		fmt.Fprintf(&b, "| Files found | %d |\n", result.FilesFound)
+		fmt.Fprintf(&b, "| Files processed | %d |\n", result.FilesFound-result.FilesIgnored)
+		fmt.Fprintf(&b, "| Files ignored | %d |\n", result.FilesIgnored)
+		fmt.Fprintf(&b, "| Total characters | %d |\n", result.TotalChars)
+		fmt.Fprintf(&b, "| Unique characters | %d |\n", result.UniqueChars)
+		if result.Authors != nil {
+			authors := make([]string, len(result.Authors.Authors))
+			for i, author := range result.Authors.Authors {
+				authors[i] = markdownCode(author)
+			}
+			fmt.Fprintf(&b, "| Lines attributed to %s | %d |\n", strings.Join(authors, ", "), result.Authors.AttributedLines)
+			fmt.Fprintf(&b, "| Lines skipped | %d |\n", result.Authors.SkippedLines)
+		}
+		if result.Duplicates != nil {
+			fmt.Fprintf(&b, "| Duplicate files skipped | %d |\n", result.Duplicates.Files)
+			fmt.Fprintf(&b, "| Bytes saved by deduplication | %d |\n", result.Duplicates.BytesSaved)
+		}
+		fmt.Fprintf(&b, "| Total time | %s |\n", result.Timing.TotalDuration)
+	}
+
+	return b.String()
+}
+
+func writeMarkdownHeader(b *strings.Builder, name string, showPercentages bool) {
+	if showPercentages {
+		fmt.Fprintf(b, "| %s | Count | Percentage |\n| :-- | --: | --: |\n", name)
+		return
+	}
+	fmt.Fprintf(b, "| %s | Count |\n| :-- | --: |\n", name)
+}
+
+func writeMarkdownRow(b *strings.Builder, cell string, count int, percentage float64, showPercentages bool) {
+	if showPercentages {
+		fmt.Fprintf(b, "| %s | %d | %.2f%% |\n", cell, count, percentage)
+		return
+	}
+	fmt.Fprintf(b, "| %s | %d |\n", cell, count)
+}
+
+// markdownCode renders s as a code span in a table cell. The span's
+// backticks outnumber any run of backticks in s, and pipes are escaped since
+// GFM splits cells on them even inside code spans. Common whitespace was
+// already replaced with names or glyphs by formatChars and formatSequences;
+// other control characters would break the row, so they are replaced.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return unicode.ReplacementChar
+		}
+		return r
+	}, s)
+
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+
+	s = strings.ReplaceAll(s, "|", `\|`)
+	// A space keeps a backtick at either end from joining the fence; one
+	// space on each side is stripped when the span is rendered
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
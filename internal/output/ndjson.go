@@ -0,0 +1,185 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+// ndjsonFile is the line of a counted file. Its counts can be summed across
+// lines, so sequences are not cut by the threshold or --top-n-seq.
+type ndjsonFile struct {
+	Type       string         `json:"type"`
+	Path       string         `json:"path"`
+	Language   string         `json:"language,omitempty"`
+	TotalChars int            `json:"total_characters"`
+	Characters map[string]int `json:"characters"`
+	Sequences  map[string]int `json:"sequences,omitempty"`
+}
+
+// ndjsonSummary is the last line, with the same fields as the json format.
+type ndjsonSummary struct {
+	Type string `json:"type"`
+	domain.JSONOutput
+}
+
+// stream is an open ndjson target.
+type stream struct {
+	w    *bufio.Writer
+	file *os.File
+}
+
+// OutputNDJSON prints the summary line of the ndjson format. The file lines
+// before it are written by OutputFile while the files are counted.
+func (o *Outputter) OutputNDJSON(
+	showPercentages bool,
+	directory string,
+	result domain.AnalysisResult,
+	includeMetadata bool,
+) error {
+	data, err := json.Marshal(ndjsonSummary{
+		Type:       "summary",
+		JSONOutput: o.jsonOutput(showPercentages, directory, result, includeMetadata),
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(o.w, string(data))
+	return err
+}
+
+// OpenStreams opens the ndjson targets, so that OutputFile can write to them
+// before the result is complete. OutputTargets then ends them with the
+// summary and closes them.
+func (o *Outputter) OpenStreams(targets []Target) error {
+	for _, target := range targets {
+		if target.Format != "ndjson" || o.streams[target] != nil {
+			continue
+		}
+
+		s := &stream{}
+		var w io.Writer = o.w
+		if target.Path != Stdout && target.Path != "" {
+			file, err := os.Create(target.Path)
+			if err != nil {
+				o.CloseStreams()
+				return err
+			}
+			s.file = file
+			w = file
+		}
+		s.w = bufio.NewWriter(w)
+
+		if o.streams == nil {
+			o.streams = make(map[Target]*stream)
+		}
+		o.streams[target] = s
+	}
+	return nil
+}
+
+// Streaming reports whether OutputFile writes anywhere.
+func (o *Outputter) Streaming() bool {
+	return len(o.streams) > 0
+}
+
+// OutputFile writes a counted file to the open ndjson targets. The filter's
+// character classes apply as they do to the summary, but not its limits,
+// which only make sense for the combined counts. Write errors are reported
+// when the stream is closed.
+func (o *Outputter) OutputFile(file domain.FileCounts) {
+	line := ndjsonFile{
+		Type:       "file",
+		Path:       file.Path,
+		Language:   fileLanguage(file.Path),
+		TotalChars: file.TotalChars,
+		Characters: make(map[string]int, len(file.Chars)),
+		Sequences:  file.Sequences,
+	}
+	for char, count := range file.Chars {
+		if o.Filter.keepChar(string(char)) {
+			line.Characters[string(char)] = count
+		}
+	}
+	if o.Filter.Only != ClassAll || o.Filter.ExcludeWhitespace {
+		line.Sequences = make(map[string]int, len(file.Sequences))
+		for sequence, count := range file.Sequences {
+			if o.Filter.keepSequence(sequence) {
+				line.Sequences[sequence] = count
+			}
+		}
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+	for _, s := range o.streams {
+		s.w.Write(data)
+		// Stdout is flushed per file, so a reader such as jq sees each line
+		// as soon as it is counted
+		if s.file == nil {
+			s.w.Flush()
+		}
+	}
+}
+
+// CloseStreams flushes and closes the streams that OutputTargets did not
+// end, such as when the analysis failed.
+func (o *Outputter) CloseStreams() error {
+	var firstErr error
+	for target := range o.streams {
+		if err := o.closeStream(target); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (o *Outputter) closeStream(target Target) error {
+	s := o.streams[target]
+	delete(o.streams, target)
+
+	err := s.w.Flush()
+	if s.file != nil {
+		if closeErr := s.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil && s.file != nil {
+		return fmt.Errorf("could not write %s: %w", target.Path, err)
+	}
+	return err
+}
+
+// languages names the language of common source file extensions.
+var languages = map[string]string{
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".cxx": "C++", ".hpp": "C++", ".hh": "C++",
+	".cs": "C#", ".go": "Go", ".rs": "Rust", ".zig": "Zig",
+	".java": "Java", ".kt": "Kotlin", ".kts": "Kotlin", ".scala": "Scala", ".groovy": "Groovy",
+	".swift": "Swift", ".m": "Objective-C", ".mm": "Objective-C++",
+	".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript", ".mts": "TypeScript", ".cts": "TypeScript",
+	".vue": "Vue", ".svelte": "Svelte",
+	".py": "Python", ".ipynb": "Jupyter Notebook", ".rb": "Ruby", ".php": "PHP", ".pl": "Perl",
+	".lua": "Lua", ".r": "R", ".jl": "Julia", ".dart": "Dart",
+	".ex": "Elixir", ".exs": "Elixir", ".erl": "Erlang", ".hs": "Haskell", ".ml": "OCaml", ".mli": "OCaml",
+	".fs": "F#", ".fsx": "F#", ".clj": "Clojure", ".cljs": "ClojureScript", ".el": "Emacs Lisp", ".lisp": "Common Lisp",
+	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell", ".fish": "Fish", ".ps1": "PowerShell", ".sql": "SQL",
+	".html": "HTML", ".css": "CSS", ".scss": "SCSS", ".sass": "Sass", ".less": "Less",
+	".md": "Markdown", ".markdown": "Markdown", ".mdx": "MDX", ".rst": "reStructuredText", ".txt": "Text",
+	".json": "JSON", ".yaml": "YAML", ".yml": "YAML", ".toml": "TOML", ".xml": "XML", ".csv": "CSV",
+}
+
+// fileLanguage returns the language of a file from its extension, or "" when
+// it is not known.
+func fileLanguage(path string) string {
+	return languages[strings.ToLower(filepath.Ext(path))]
+}
@@ -0,0 +1,131 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+// OutputOpenMetrics prints the counts as OpenMetrics gauges, which the
+// Prometheus text format reads as well, so a node exporter textfile
+// collector can pick them up. With metadata, the file, character and timing
+// numbers are added.
+func (o *Outputter) OutputOpenMetrics(result domain.AnalysisResult, directory string, includeMetadata bool) error {
+	_, err := fmt.Fprint(o.w, o.renderOpenMetrics(result, directory, includeMetadata))
+	return err
+}
+
+func (o *Outputter) renderOpenMetrics(result domain.AnalysisResult, directory string, includeMetadata bool) string {
+	var b strings.Builder
+
+	metricFamily(&b, "symbolista_char_count", "Occurrences of a character.", "")
+	for _, sample := range uniqueLabels(result.CharCounts, func(c domain.CharCount) (string, int) {
+		return c.Char, c.Count
+	}) {
+		fmt.Fprintf(&b, "symbolista_char_count{char=%s} %d\n", sample.label, sample.value)
+	}
+	metricFamily(&b, "symbolista_sequence_count", "Occurrences of a character sequence.", "")
+	for _, sample := range uniqueLabels(result.SequenceCounts, func(seq domain.SequenceCount) (string, int) {
+		return seq.Sequence, seq.Count
+	}) {
+		fmt.Fprintf(&b, "symbolista_sequence_count{seq=%s} %d\n", sample.label, sample.value)
+	}
+
+	if len(result.Roots) > 0 {
+		metricFamily(&b, "symbolista_source_characters", "Characters counted in a source, before weighting.", "")
+		for _, sample := range uniqueLabels(result.Roots, func(root domain.RootResult) (string, int) {
+			return root.Path, root.TotalChars
+		}) {
+			fmt.Fprintf(&b, "symbolista_source_characters{source=%s} %d\n", sample.label, sample.value)
+		}
+	}
+
+	if includeMetadata {
+		metricFamily(&b, "symbolista_info", "The version and directory of the analysis.", "")
+		fmt.Fprintf(&b, "symbolista_info{version=%s,directory=%s} 1\n", labelValue(o.ToolVersion), labelValue(directory))
+
+		gauge(&b, "symbolista_files_found", "Files found in the directory.", result.FilesFound)
+		gauge(&b, "symbolista_files_processed", "Files counted.", result.FilesFound-result.FilesIgnored)
+		gauge(&b, "symbolista_files_ignored", "Files and directories ignored.", result.FilesIgnored)
+		gauge(&b, "symbolista_characters", "Characters counted.", result.TotalChars)
+		gauge(&b, "symbolista_unique_characters", "Distinct characters counted.", result.UniqueChars)
+
+		if result.Authors != nil {
+			gauge(&b, "symbolista_attributed_lines", "Lines attributed to the authors.", result.Authors.AttributedLines)
+			gauge(&b, "symbolista_skipped_lines", "Lines attributed to others.", result.Authors.SkippedLines)
+		}
+		if result.Duplicates != nil {
+			gauge(&b, "symbolista_duplicate_files", "Files skipped because their content was already counted.", result.Duplicates.Files)
+			metricFamily(&b, "symbolista_duplicate_bytes", "Size of the skipped duplicate files.", "bytes")
+			fmt.Fprintf(&b, "symbolista_duplicate_bytes %d\n", result.Duplicates.BytesSaved)
+		}
+
+		metricFamily(&b, "symbolista_duration_seconds", "Time spent in each phase of the analysis.", "seconds")
+		for _, phase := range []struct {
+			name     string
+			duration time.Duration
+		}{
+			{"gitignore", result.Timing.GitignoreDuration},
+			{"traversal", result.Timing.TraversalDuration},
+			{"sorting", result.Timing.SortingDuration},
+			{"total", result.Timing.TotalDuration},
+		} {
+			fmt.Fprintf(&b, "symbolista_duration_seconds{phase=%s} %s\n", labelValue(phase.name), strconv.FormatFloat(phase.duration.Seconds(), 'f', -1, 64))
+		}
+	}
+
+	b.WriteString("# EOF\n")
+	return b.String()
+}
+
+// metricFamily writes the metadata lines of a gauge.
+func metricFamily(b *strings.Builder, name, help, unit string) {
+	fmt.Fprintf(b, "# TYPE %s gauge\n", name)
+	if unit != "" {
+		fmt.Fprintf(b, "# UNIT %s %s\n", name, unit)
+	}
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+}
+
+func gauge(b *strings.Builder, name, help string, value int) {
+	metricFamily(b, name, help, "")
+	fmt.Fprintf(b, "%s %d\n", name, value)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue quotes a label value, escaping backslashes, double quotes and
+// line feeds as OpenMetrics requires. Bytes that are not valid UTF-8, which
+// --ascii-only=false can count, are replaced with U+FFFD.
+func labelValue(s string) string {
+	return `"` + labelEscaper.Replace(strings.ToValidUTF8(s, string(utf8.RuneError))) + `"`
+}
+
+type labeledSample struct {
+	label string
+	value int
+}
+
+// uniqueLabels returns a sample per distinct label value, in order of first
+// appearance. A series may only appear once, but with --ascii-only=false
+// the same character can be counted twice, and labels made valid UTF-8 can
+// collide, so their values are added up.
+func uniqueLabels[T any](items []T, sample func(T) (string, int)) []labeledSample {
+	var samples []labeledSample
+	index := make(map[string]int)
+	for _, item := range items {
+		label, value := sample(item)
+		label = labelValue(label)
+		if i, ok := index[label]; ok {
+			samples[i].value += value
+			continue
+		}
+		index[label] = len(samples)
+		samples = append(samples, labeledSample{label, value})
+	}
+	return samples
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+func TestLabelValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"{", `"{"`},
+		{"=>", `"=>"`},
+		{`"`, `"\""`},
+		{`\`, `"\\"`},
+		{"\n", `"\n"`},
+		{"\t", "\"\t\""},
+		{`a\"` + "\n", `"a\\\"\n"`},
+		{"\xe3\x81", "\"\ufffd\""},
+	}
+
+	for _, tt := range tests {
+		if got := labelValue(tt.value); got != tt.expected {
+			t.Errorf("labelValue(%q) = %s, expected %s", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestOutputOpenMetrics(t *testing.T) {
+	result := domain.AnalysisResult{
+		CharCounts:     domain.CharCounts{{Char: "{", Count: 3}, {Char: `"`, Count: 1}},
+		SequenceCounts: domain.SequenceCounts{{Sequence: "=>", Count: 2}},
+		FilesFound:     3,
+		FilesIgnored:   1,
+		TotalChars:     4,
+		UniqueChars:    2,
+		Timing:         domain.TimingBreakdown{TotalDuration: 1500 * time.Millisecond},
+	}
+
+	var buf bytes.Buffer
+	outputter := NewOutputter(&buf)
+	outputter.ToolVersion = "v1.0.0"
+	if err := outputter.OutputOpenMetrics(result, "src", true); err != nil {
+		t.Fatalf("OutputOpenMetrics failed: %v", err)
+	}
+	got := buf.String()
+
+	for _, line := range []string{
+		`symbolista_char_count{char="{"} 3`,
+		`symbolista_char_count{char="\""} 1`,
+		`symbolista_sequence_count{seq="=>"} 2`,
+		`symbolista_info{version="v1.0.0",directory="src"} 1`,
+		`symbolista_files_processed 2`,
+		`symbolista_characters 4`,
+		`symbolista_duration_seconds{phase="total"} 1.5`,
+	} {
+		if !strings.Contains(got, "\n"+line+"\n") {
+			t.Errorf("Expected line %q in\n%s", line, got)
+		}
+	}
+	if !strings.HasSuffix(got, "\n# EOF\n") {
+		t.Errorf("Expected the exposition to end with # EOF, got\n%s", got)
+	}
+
+	// Every sample belongs to the family declared before it
+	family := ""
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+		if name, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			family, _, _ = strings.Cut(name, " ")
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name := strings.FieldsFunc(line, func(r rune) bool { return r == '{' || r == ' ' })[0]; name != family {
+			t.Errorf("Sample %q outside of its family %q", line, family)
+		}
+	}
+}
+
+func TestOutputOpenMetricsUniqueSeries(t *testing.T) {
+	result := domain.AnalysisResult{
+		CharCounts:     domain.CharCounts{{Char: "ã", Count: 3}, {Char: "a", Count: 2}, {Char: "ã", Count: 1}},
+		SequenceCounts: domain.SequenceCounts{{Sequence: "\xe3\x81", Count: 2}, {Sequence: "\xc3", Count: 1}},
+	}
+
+	var buf bytes.Buffer
+	if err := NewOutputter(&buf).OutputOpenMetrics(result, "", false); err != nil {
+		t.Fatalf("OutputOpenMetrics failed: %v", err)
+	}
+	got := buf.String()
+
+	if !utf8.ValidString(got) {
+		t.Errorf("Expected valid UTF-8, got %q", got)
+	}
+	for _, line := range []string{
+		`symbolista_char_count{char="ã"} 4`,
+		`symbolista_char_count{char="a"} 2`,
+		"symbolista_sequence_count{seq=\"\ufffd\"} 3",
+	} {
+		if strings.Count(got, "\n"+line+"\n") != 1 {
+			t.Errorf("Expected line %q once in\n%s", line, got)
+		}
+	}
+	if n := strings.Count(got, "symbolista_char_count{"); n != 2 {
+		t.Errorf("Expected 2 character series, got %d in\n%s", n, got)
+	}
+}
@@ -4,17 +4,35 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/ogdakke/symbolista/internal/domain"
 )
 
 type Outputter struct {
+	w io.Writer
+	// Chart configures the svg format
+	Chart ChartOptions
+	// Table configures the table format
+	Table TableOptions
+	// Filter narrows the result before it is written
+	Filter Filter
+	// Template renders the template format
+	Template *template.Template
+	// ToolVersion is recorded in the JSON metadata
+	ToolVersion string
+	// streams are the ndjson targets that files are written to while they
+	// are counted
+	streams map[Target]*stream
 }
 
-func NewOutputter() *Outputter {
-	return &Outputter{}
+// NewOutputter returns an Outputter that writes to w.
+func NewOutputter(w io.Writer) *Outputter {
+	return &Outputter{w: w}
 }
 
 func (o *Outputter) Output(
@@ -23,73 +41,50 @@ func (o *Outputter) Output(
 	showPercentages bool,
 	directory string,
 	includeMetadata bool,
-) {
+) error {
 	switch kind {
 	case "json":
 
-		o.OutputJSON(showPercentages, directory, result, includeMetadata)
+		return o.OutputJSON(showPercentages, directory, result, includeMetadata)
+	case "ndjson":
+
+		return o.OutputNDJSON(showPercentages, directory, result, includeMetadata)
 	case "csv":
 
-		o.OutputCSV(result.CharCounts, result.SequenceCounts, showPercentages)
-	default:
+		return o.OutputCSV(result.CharCounts, result.SequenceCounts, showPercentages)
+	case "markdown":
 
-		o.OutputTable(result.CharCounts, result.SequenceCounts, showPercentages)
-	}
-}
+		return o.OutputMarkdown(result, showPercentages, directory, includeMetadata)
+	case "html":
 
-func (o *Outputter) OutputTable(
-	counts domain.CharCounts,
-	sequences domain.SequenceCounts,
-	showPercentages bool,
-) {
-	width := 35
-	fmt.Println("Characters:")
-	fmt.Println(strings.Repeat("-", width))
-	fmt.Printf("%-10s %-10s", "Character", "Count")
-	if showPercentages {
-		fmt.Printf(" %-12s", "Percentage")
-	}
-	fmt.Println()
-	fmt.Println(strings.Repeat("-", width))
+		return o.OutputHTML(result, showPercentages, directory, includeMetadata)
+	case "openmetrics":
 
-	formatChars(counts, func(char string, count int, percentage float64) {
-		fmt.Printf("%-10s %-10d", char, count)
-		if showPercentages {
-			fmt.Printf(" %-12.2f%%", percentage)
-		}
-		fmt.Println()
-	})
-	fmt.Println(strings.Repeat("-", width))
+		return o.OutputOpenMetrics(result, directory, includeMetadata)
+	case "template":
 
-	if len(sequences) > 0 {
-		seqs := formatSequences(sequences)
-		fmt.Printf("\nSequences (2-3 chars):\n")
-		fmt.Println(strings.Repeat("-", width))
-		fmt.Printf("%-10s %-10s", "Sequence", "Count")
-		if showPercentages {
-			fmt.Printf(" %-12s", "Percentage")
-		}
-		fmt.Println()
-		fmt.Println(strings.Repeat("-", width))
+		return o.OutputTemplate(result, showPercentages, directory, includeMetadata)
+	case "svg":
 
-		for _, seq := range seqs {
-			fmt.Printf("%-10s %-10d", seq.Sequence, seq.Count)
-			if showPercentages {
-				fmt.Printf(" %-12.2f%%", seq.Percentage)
-			}
-			fmt.Println()
+		return o.OutputSVG(result, directory)
+	default:
+
+		if err := o.OutputTable(result.CharCounts, result.SequenceCounts, showPercentages); err != nil {
+			return err
+		}
+		if len(result.Roots) > 0 {
+			return o.OutputRootsTable(result.Roots)
 		}
-		fmt.Println(strings.Repeat("-", width))
 	}
+	return nil
 }
 
 func (o *Outputter) OutputCSV(
 	counts domain.CharCounts,
 	sequences domain.SequenceCounts,
 	showPercentages bool,
-) {
-	writer := csv.NewWriter(os.Stdout)
-	defer writer.Flush()
+) error {
+	writer := csv.NewWriter(o.w)
 
 	headers := []string{"type", "sequence", "count"}
 	if showPercentages {
@@ -113,6 +108,9 @@ func (o *Outputter) OutputCSV(
 		}
 		writer.Write(row)
 	}
+
+	writer.Flush()
+	return writer.Error()
 }
 
 func (o *Outputter) OutputJSON(
@@ -120,24 +118,54 @@ func (o *Outputter) OutputJSON(
 	directory string,
 	result domain.AnalysisResult,
 	includeMetadata bool,
-) {
+) error {
+	data, err := json.MarshalIndent(o.jsonOutput(showPercentages, directory, result, includeMetadata), "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(o.w, string(data))
+	return err
+}
+
+func (o *Outputter) jsonOutput(
+	showPercentages bool,
+	directory string,
+	result domain.AnalysisResult,
+	includeMetadata bool,
+) domain.JSONOutput {
 	counts := result.CharCounts
+	roots := result.Roots
 
 	if !showPercentages {
+		// The result is shared with the other targets, so the percentages
+		// are cleared on copies
+		counts = slices.Clone(counts)
 		for i := range counts {
 			counts[i].Percentage = 0
 		}
+		roots = slices.Clone(roots)
+		for r := range roots {
+			roots[r].Characters = slices.Clone(roots[r].Characters)
+			for i := range roots[r].Characters {
+				roots[r].Characters[i].Percentage = 0
+			}
+		}
 	}
 
 	output := domain.JSONOutput{
+		SchemaVersion: domain.SchemaVersion,
 		Result: domain.JSONResult{
 			Characters: counts,
 			Sequences:  result.SequenceCounts,
+			Roots:      roots,
 		},
 	}
 
 	if includeMetadata {
 		output.Metadata = &domain.JSONMetadata{
+			ToolVersion:     o.ToolVersion,
+			GeneratedAt:     time.Now().UTC().Truncate(time.Second),
+			Config:          result.Config,
 			Directory:       directory,
 			FilesFound:      result.FilesFound,
 			FilesProcessed:  result.FilesFound - result.FilesIgnored,
@@ -145,50 +173,54 @@ func (o *Outputter) OutputJSON(
 			TotalCharacters: result.TotalChars,
 			UniqueChars:     result.UniqueChars,
 			Timing:          result.Timing,
+			Authors:         result.Authors,
+			Duplicates:      result.Duplicates,
 		}
 	}
-
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		fmt.Printf("Error marshaling JSON: %v\n", err)
-		return
-	}
-	fmt.Println(string(data))
+	return output
 }
 
 type OnCharFunc func(char string, count int, percentage float64)
 
 func formatChars(counts domain.CharCounts, onChar OnCharFunc) domain.CharCounts {
 	for _, c := range counts {
-		char := c.Char
-		switch c.Char {
-		case " ":
-			char = "<space>"
-		case "\t":
-			char = "<tab>"
-		case "\n":
-			char = "<newline>"
-		case "\r":
-			char = "<return>"
-		case "\f":
-			char = "<formfeed>"
-		case "\v":
-			char = "<vert_tab>"
-		}
-
-		onChar(char, c.Count, c.Percentage)
+		onChar(charName(c.Char), c.Count, c.Percentage)
 	}
 	return counts
 }
 
+// charName names whitespace characters, such as <space>, and returns other
+// characters as they are.
+func charName(char string) string {
+	switch char {
+	case " ":
+		return "<space>"
+	case "\t":
+		return "<tab>"
+	case "\n":
+		return "<newline>"
+	case "\r":
+		return "<return>"
+	case "\f":
+		return "<formfeed>"
+	case "\v":
+		return "<vert_tab>"
+	}
+	return char
+}
+
 func formatSequences(seqs domain.SequenceCounts) domain.SequenceCounts {
 	var sequencesFormatted domain.SequenceCounts = make(domain.SequenceCounts, 0)
 	for _, seq := range seqs {
-		seq.Sequence = strings.ReplaceAll(seq.Sequence, "\n", "↵")
-		seq.Sequence = strings.ReplaceAll(seq.Sequence, " ", "⎵")
-		seq.Sequence = strings.ReplaceAll(seq.Sequence, "\t", "⇥")
-		seq.Sequence = strings.ReplaceAll(seq.Sequence, "\r", "⏎")
+		seq.Sequence = whitespaceGlyphs(seq.Sequence)
 		sequencesFormatted = append(sequencesFormatted, seq)
 	}
 	return sequencesFormatted
 }
+
+var glyphReplacer = strings.NewReplacer("\n", "↵", " ", "⎵", "\t", "⇥", "\r", "⏎")
+
+// whitespaceGlyphs replaces whitespace with the symbols the TUI shows for it.
+func whitespaceGlyphs(s string) string {
+	return glyphReplacer.Replace(s)
+}
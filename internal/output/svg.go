@@ -0,0 +1,232 @@
+package output
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+// ChartKind is the kind of chart the svg format draws.
+type ChartKind string
+
+const (
+	// ChartBars draws a bar per character or sequence
+	ChartBars ChartKind = "bars"
+	// ChartPareto adds a line with the cumulative percentage to the bars
+	ChartPareto ChartKind = "pareto"
+)
+
+var ChartKinds = []ChartKind{ChartBars, ChartPareto}
+
+// ChartItems selects what the chart shows.
+type ChartItems string
+
+const (
+	ChartCharacters ChartItems = "characters"
+	ChartSequences  ChartItems = "sequences"
+)
+
+var ChartItemKinds = []ChartItems{ChartCharacters, ChartSequences}
+
+// ChartLabel selects the label above each bar, like the TUI's LabelMode.
+type ChartLabel string
+
+const (
+	ChartLabelCount      ChartLabel = "count"
+	ChartLabelPercentage ChartLabel = "percentage"
+)
+
+var ChartLabels = []ChartLabel{ChartLabelCount, ChartLabelPercentage}
+
+// ChartOptions configures the svg format.
+type ChartOptions struct {
+	Kind   ChartKind
+	Items  ChartItems
+	Labels ChartLabel
+	// Top is the number of bars; 0 means DefaultChartTop
+	Top int
+}
+
+const DefaultChartTop = 30
+
+// Validate reports an error for unknown option values.
+func (c ChartOptions) Validate() error {
+	if !slices.Contains(ChartKinds, c.Kind) {
+		return fmt.Errorf("unknown chart %q (bars, pareto)", c.Kind)
+	}
+	if !slices.Contains(ChartItemKinds, c.Items) {
+		return fmt.Errorf("unknown chart items %q (characters, sequences)", c.Items)
+	}
+	if !slices.Contains(ChartLabels, c.Labels) {
+		return fmt.Errorf("unknown chart labels %q (count, percentage)", c.Labels)
+	}
+	if c.Top < 0 {
+		return fmt.Errorf("chart top must not be negative")
+	}
+	return nil
+}
+
+// The TUI's bar colors, as the xterm palette renders them
+var chartColors = []string{"#00ff00", "#ff0000", "#ffff00", "#00ffff", "#ff00ff", "#5c5cff", "#00cdcd", "#cd00cd", "#0000ee", "#cdcd00", "#00cd00", "#cd0000"}
+
+const (
+	chartSlot         = 32
+	chartBarWidth     = 24
+	chartPlotHeight   = 300
+	chartMarginTop    = 48
+	chartMarginBottom = 40
+	chartMarginLeft   = 56
+	chartMarginRight  = 56
+	chartMinWidth     = 400
+	chartBackground   = "#1e1e1e"
+	chartForeground   = "#d4d4d4"
+	chartGrid         = "#3c3c3c"
+)
+
+type chartBar struct {
+	label      string
+	count      int
+	percentage float64
+}
+
+// OutputSVG prints the top characters or sequences as an SVG bar chart. The
+// same result always renders to the same bytes.
+func (o *Outputter) OutputSVG(result domain.AnalysisResult, directory string) error {
+	_, err := fmt.Fprint(o.w, renderSVG(result, directory, o.Chart))
+	return err
+}
+
+func renderSVG(result domain.AnalysisResult, directory string, opts ChartOptions) string {
+	if opts.Kind == "" {
+		opts.Kind = ChartBars
+	}
+	if opts.Items == "" {
+		opts.Items = ChartCharacters
+	}
+	if opts.Labels == "" {
+		opts.Labels = ChartLabelCount
+	}
+	top := opts.Top
+	if top <= 0 {
+		top = DefaultChartTop
+	}
+
+	var bars []chartBar
+	if opts.Items == ChartSequences {
+		for _, seq := range result.SequenceCounts {
+			bars = append(bars, chartBar{whitespaceGlyphs(seq.Sequence), seq.Count, seq.Percentage})
+		}
+	} else {
+		for _, c := range result.CharCounts {
+			bars = append(bars, chartBar{whitespaceGlyphs(c.Char), c.Count, c.Percentage})
+		}
+	}
+	if len(bars) > top {
+		bars = bars[:top]
+	}
+
+	highest := 1
+	for _, bar := range bars {
+		highest = max(highest, bar.count)
+	}
+
+	// Wide enough for the title when there are only a few bars
+	width := max(chartMarginLeft+len(bars)*chartSlot+chartMarginRight, chartMinWidth)
+	height := chartMarginTop + chartPlotHeight + chartMarginBottom
+	baseline := chartMarginTop + chartPlotHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="ui-monospace, Menlo, Consolas, monospace" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", chartBackground)
+	fmt.Fprintf(&b, `<text x="%d" y="24" fill="%s" font-size="14">%s</text>`+"\n", chartMarginLeft, chartForeground, svgText(chartTitle(opts, len(bars), directory)))
+
+	// Grid lines with count ticks on the left
+	for i := 0; i <= 4; i++ {
+		y := baseline - chartPlotHeight*i/4
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s"/>`+"\n", chartMarginLeft, y, width-chartMarginRight, y, chartGrid)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s" text-anchor="end">%s</text>`+"\n", chartMarginLeft-6, y+4, chartForeground, shortCount(highest*i/4))
+	}
+
+	for i, bar := range bars {
+		x := chartMarginLeft + i*chartSlot + (chartSlot-chartBarWidth)/2
+		barHeight := chartPlotHeight * bar.count / highest
+		center := x + chartBarWidth/2
+
+		label := shortCount(bar.count)
+		if opts.Labels == ChartLabelPercentage {
+			label = fmt.Sprintf("%.1f%%", bar.percentage)
+		}
+
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"><title>%s: %d (%.2f%%)</title></rect>`+"\n",
+			x, baseline-barHeight, chartBarWidth, barHeight, chartColors[i%len(chartColors)], svgText(bar.label), bar.count, bar.percentage)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s" font-size="10" text-anchor="middle">%s</text>`+"\n", center, baseline-barHeight-4, chartForeground, label)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s" text-anchor="middle" xml:space="preserve">%s</text>`+"\n", center, baseline+18, chartForeground, svgText(bar.label))
+	}
+
+	if opts.Kind == ChartPareto && len(bars) > 0 {
+		writeParetoLine(&b, bars, width, baseline)
+	}
+
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// writeParetoLine draws the cumulative percentage of the bars, on a 0-100%
+// axis on the right.
+func writeParetoLine(b *strings.Builder, bars []chartBar, width, baseline int) {
+	right := width - chartMarginRight
+	for i := 0; i <= 4; i++ {
+		y := baseline - chartPlotHeight*i/4
+		fmt.Fprintf(b, `<text x="%d" y="%d" fill="%s">%d%%</text>`+"\n", right+6, y+4, chartForeground, 25*i)
+	}
+
+	var points []string
+	var cumulative float64
+	for i, bar := range bars {
+		cumulative += bar.percentage
+		x := chartMarginLeft + i*chartSlot + chartSlot/2
+		y := float64(baseline) - float64(chartPlotHeight)*min(cumulative, 100)/100
+		points = append(points, fmt.Sprintf("%d,%s", x, strconv.FormatFloat(y, 'f', 1, 64)))
+	}
+	fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", strings.Join(points, " "), chartForeground)
+	for _, point := range points {
+		x, y, _ := strings.Cut(point, ",")
+		fmt.Fprintf(b, `<circle cx="%s" cy="%s" r="3" fill="%s"/>`+"\n", x, y, chartForeground)
+	}
+	fmt.Fprintf(b, `<text x="%d" y="%d" fill="%s" text-anchor="end">cumulative %.1f%%</text>`+"\n", right, chartMarginTop-8, chartForeground, cumulative)
+}
+
+func chartTitle(opts ChartOptions, n int, directory string) string {
+	title := fmt.Sprintf("Top %d %s", n, opts.Items)
+	if directory != "" {
+		title += " in " + directory
+	}
+	return title
+}
+
+// svgText escapes s for SVG text. Control characters that whitespaceGlyphs
+// leaves, such as \f and \v, are not allowed in XML and are replaced.
+func svgText(s string) string {
+	return html.EscapeString(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return unicode.ReplacementChar
+		}
+		return r
+	}, s))
+}
+
+// shortCount formats a count like the TUI's bar labels: 950, 1.2k, 3.4M.
+func shortCount(count int) string {
+	switch {
+	case count >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(count)/1000000)
+	case count >= 1000:
+		return fmt.Sprintf("%.1fk", float64(count)/1000)
+	}
+	return strconv.Itoa(count)
+}
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+func TestOutputSVGIsValidXML(t *testing.T) {
+	result := domain.AnalysisResult{
+		CharCounts: domain.CharCounts{
+			{Char: "\f", Count: 5, Percentage: 25},
+			{Char: "\v", Count: 4, Percentage: 20},
+			{Char: "<", Count: 3, Percentage: 15},
+			{Char: "&", Count: 3, Percentage: 15},
+			{Char: "\xc3", Count: 2, Percentage: 10},
+			{Char: "\n", Count: 2, Percentage: 10},
+			{Char: " ", Count: 1, Percentage: 5},
+		},
+		SequenceCounts: domain.SequenceCounts{{Sequence: "\f\v\"", Count: 2, Percentage: 100}},
+	}
+
+	for _, items := range ChartItemKinds {
+		t.Run(string(items), func(t *testing.T) {
+			var out bytes.Buffer
+			outputter := NewOutputter(&out)
+			outputter.Chart = ChartOptions{Kind: ChartPareto, Items: items, Labels: ChartLabelCount}
+			if err := outputter.OutputSVG(result, "dir\x1b"); err != nil {
+				t.Fatalf("OutputSVG failed: %v", err)
+			}
+
+			decoder := xml.NewDecoder(strings.NewReader(out.String()))
+			for {
+				_, err := decoder.Token()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Expected valid XML, got %v in\n%s", err, out.String())
+				}
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	if err := NewOutputter(&out).OutputSVG(result, ""); err != nil {
+		t.Fatalf("OutputSVG failed: %v", err)
+	}
+	for _, label := range []string{"↵", "⎵", "&lt;", "&amp;", "�"} {
+		if !strings.Contains(out.String(), label) {
+			t.Errorf("Expected %q in the chart", label)
+		}
+	}
+}
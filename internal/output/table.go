@@ -0,0 +1,318 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+// TableMode turns a feature of the table format on or off, or leaves it to
+// whether the table is written to a terminal.
+type TableMode string
+
+const (
+	TableAuto   TableMode = "auto"
+	TableAlways TableMode = "always"
+	TableNever  TableMode = "never"
+)
+
+var TableModes = []TableMode{TableAuto, TableAlways, TableNever}
+
+// TableOptions configures the table format. The zero value prints the plain
+// fixed-width table.
+type TableOptions struct {
+	// Bars draws a bar proportional to the count after each row
+	Bars TableMode
+	// Color colors the headers and bars with the TUI's colors
+	Color TableMode
+	// Terminal is set when the table is written to a terminal
+	Terminal bool
+	// Width is the number of columns the table may use; 0 means
+	// DefaultTableWidth
+	Width int
+}
+
+const DefaultTableWidth = 80
+
+// Validate reports an error for unknown modes.
+func (t TableOptions) Validate() error {
+	if !slices.Contains(TableModes, t.Bars) {
+		return fmt.Errorf("unknown bars mode %q (auto, always, never)", t.Bars)
+	}
+	if !slices.Contains(TableModes, t.Color) {
+		return fmt.Errorf("unknown color mode %q (auto, always, never)", t.Color)
+	}
+	return nil
+}
+
+func (t TableOptions) enabled(mode TableMode) bool {
+	return mode == TableAlways || mode != TableNever && t.Terminal
+}
+
+// plain reports whether the table keeps the fixed layout it had before bars
+// and colors, so that scripts reading it from a pipe or file keep working.
+func (t TableOptions) plain() bool {
+	return !t.Terminal && !t.enabled(t.Bars) && !t.enabled(t.Color)
+}
+
+// The TUI's bar colors, as indexes into the terminal's 16 colors
+var tableColors = []int{10, 9, 11, 14, 13, 12, 6, 5, 4, 3, 2, 1}
+
+const (
+	tableMinBarWidth = 10
+	tableDim         = 8
+)
+
+// table holds rows of cells, laid out in columns as wide as their widest
+// cell in terminal cells, and a value per row for its bar.
+type table struct {
+	titles []string
+	widths []int
+	rows   [][]string
+	values []float64
+}
+
+// newTable returns a table with columns at least minWidths wide.
+func newTable(titles []string, minWidths []int) *table {
+	t := &table{titles: titles, widths: slices.Clone(minWidths[:len(titles)])}
+	for i, title := range titles {
+		t.widths[i] = max(t.widths[i], runewidth.StringWidth(title))
+	}
+	return t
+}
+
+func (t *table) add(value float64, cells ...string) {
+	for i, cell := range cells {
+		t.widths[i] = max(t.widths[i], runewidth.StringWidth(cell))
+	}
+	t.rows = append(t.rows, cells)
+	t.values = append(t.values, value)
+}
+
+func (o *Outputter) OutputTable(
+	counts domain.CharCounts,
+	sequences domain.SequenceCounts,
+	showPercentages bool,
+) error {
+	if o.Table.plain() {
+		return o.outputPlainTable(counts, sequences, showPercentages)
+	}
+
+	var b strings.Builder
+	titles := []string{"Character", "Count"}
+	if showPercentages {
+		titles = append(titles, "Percentage")
+	}
+
+	chars := newTable(titles, []int{10, 10, 12})
+	formatChars(counts, func(char string, count int, percentage float64) {
+		chars.add(float64(count), tableCells(char, count, percentage, showPercentages)...)
+	})
+	o.writeTable(&b, "Characters:", chars)
+
+	if len(sequences) > 0 {
+		titles[0] = "Sequence"
+		seqs := newTable(titles, []int{10, 10, 12})
+		for _, seq := range formatSequences(sequences) {
+			seqs.add(float64(seq.Count), tableCells(seq.Sequence, seq.Count, seq.Percentage, showPercentages)...)
+		}
+		b.WriteString("\n")
+		o.writeTable(&b, "Sequences (2-3 chars):", seqs)
+	}
+
+	_, err := fmt.Fprint(o.w, b.String())
+	return err
+}
+
+func tableCells(text string, count int, percentage float64, showPercentages bool) []string {
+	cells := []string{text, strconv.Itoa(count)}
+	if showPercentages {
+		cells = append(cells, fmt.Sprintf("%.2f%%", percentage))
+	}
+	return cells
+}
+
+// OutputRootsTable prints the per-source breakdown of a weighted analysis of
+// several directories and history files.
+func (o *Outputter) OutputRootsTable(roots []domain.RootResult) error {
+	if o.Table.plain() {
+		return o.outputPlainRootsTable(roots)
+	}
+
+	var b strings.Builder
+	sources := newTable([]string{"Source", "Weight", "Files", "Characters", "Share"}, []int{0, 8, 8, 12, 12})
+	for _, root := range roots {
+		sources.add(root.Share,
+			root.Path,
+			strconv.FormatFloat(root.Weight, 'g', -1, 64),
+			strconv.Itoa(root.FilesFound-root.FilesIgnored),
+			strconv.Itoa(root.TotalChars),
+			fmt.Sprintf("%.2f%%", root.Share),
+		)
+	}
+	b.WriteString("\n")
+	o.writeTable(&b, "Sources (weighted):", sources)
+
+	_, err := fmt.Fprint(o.w, b.String())
+	return err
+}
+
+func (o *Outputter) outputPlainTable(
+	counts domain.CharCounts,
+	sequences domain.SequenceCounts,
+	showPercentages bool,
+) error {
+	var b strings.Builder
+	width := 35
+	fmt.Fprintln(&b, "Characters:")
+	fmt.Fprintln(&b, strings.Repeat("-", width))
+	fmt.Fprintf(&b, "%-10s %-10s", "Character", "Count")
+	if showPercentages {
+		fmt.Fprintf(&b, " %-12s", "Percentage")
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, strings.Repeat("-", width))
+
+	formatChars(counts, func(char string, count int, percentage float64) {
+		fmt.Fprintf(&b, "%-10s %-10d", char, count)
+		if showPercentages {
+			fmt.Fprintf(&b, " %-12.2f%%", percentage)
+		}
+		fmt.Fprintln(&b)
+	})
+	fmt.Fprintln(&b, strings.Repeat("-", width))
+
+	if len(sequences) > 0 {
+		seqs := formatSequences(sequences)
+		fmt.Fprintf(&b, "\nSequences (2-3 chars):\n")
+		fmt.Fprintln(&b, strings.Repeat("-", width))
+		fmt.Fprintf(&b, "%-10s %-10s", "Sequence", "Count")
+		if showPercentages {
+			fmt.Fprintf(&b, " %-12s", "Percentage")
+		}
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, strings.Repeat("-", width))
+
+		for _, seq := range seqs {
+			fmt.Fprintf(&b, "%-10s %-10d", seq.Sequence, seq.Count)
+			if showPercentages {
+				fmt.Fprintf(&b, " %-12.2f%%", seq.Percentage)
+			}
+			fmt.Fprintln(&b)
+		}
+		fmt.Fprintln(&b, strings.Repeat("-", width))
+	}
+
+	_, err := fmt.Fprint(o.w, b.String())
+	return err
+}
+
+func (o *Outputter) outputPlainRootsTable(roots []domain.RootResult) error {
+	var b strings.Builder
+	pathWidth := len("Source")
+	for _, root := range roots {
+		pathWidth = max(pathWidth, len(root.Path))
+	}
+	width := pathWidth + 47
+
+	fmt.Fprintf(&b, "\nSources (weighted):\n")
+	fmt.Fprintln(&b, strings.Repeat("-", width))
+	fmt.Fprintf(&b, "%-*s %-8s %-8s %-12s %-12s\n", pathWidth, "Source", "Weight", "Files", "Characters", "Share")
+	fmt.Fprintln(&b, strings.Repeat("-", width))
+	for _, root := range roots {
+		fmt.Fprintf(&b, "%-*s %-8g %-8d %-12d %-12.2f%%\n", pathWidth, root.Path, root.Weight, root.FilesFound-root.FilesIgnored, root.TotalChars, root.Share)
+	}
+	fmt.Fprintln(&b, strings.Repeat("-", width))
+
+	_, err := fmt.Fprint(o.w, b.String())
+	return err
+}
+
+// writeTable prints the table below a title, with a bar after each row when
+// they are enabled and fit in the width.
+func (o *Outputter) writeTable(b *strings.Builder, title string, t *table) {
+	color := o.Table.enabled(o.Table.Color)
+
+	width := len(t.widths)
+	for _, w := range t.widths {
+		width += w
+	}
+
+	barWidth := 0
+	if o.Table.enabled(o.Table.Bars) {
+		available := o.Table.Width
+		if available <= 0 {
+			available = DefaultTableWidth
+		}
+		if available-width >= tableMinBarWidth {
+			barWidth = available - width
+		}
+	}
+	highest := 0.0
+	for _, value := range t.values {
+		highest = max(highest, value)
+	}
+
+	separator := ansi(strings.Repeat("-", width+barWidth), color, ansiColor(tableDim))
+
+	fmt.Fprintln(b, ansi(title, color, "1"))
+	fmt.Fprintln(b, separator)
+	fmt.Fprintln(b, ansi(t.line(t.titles), color, "1"))
+	fmt.Fprintln(b, separator)
+	for i, row := range t.rows {
+		rowColor := ansiColor(tableColors[i%len(tableColors)])
+		line := t.line(row)
+		if color {
+			// Only the first cell is colored, not the padding after it
+			line = ansi(row[0], true, rowColor) + line[len(row[0]):]
+		}
+		if barWidth > 0 && highest > 0 {
+			line += " " + ansi(bar(t.values[i]/highest*float64(barWidth-1)), color, rowColor)
+		}
+		fmt.Fprintln(b, line)
+	}
+	fmt.Fprintln(b, separator)
+}
+
+// line pads the cells to the column widths.
+func (t *table) line(cells []string) string {
+	var b strings.Builder
+	for i, cell := range cells {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(cell)
+		b.WriteString(strings.Repeat(" ", t.widths[i]-runewidth.StringWidth(cell)))
+	}
+	return b.String()
+}
+
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// bar draws a bar of length cells, in eighths of a cell.
+func bar(length float64) string {
+	eighths := int(math.Round(length * 8))
+	return strings.Repeat("█", eighths/8) + barEighths[eighths%8]
+}
+
+// ansiColor returns the SGR parameter of one of the terminal's 16 colors.
+func ansiColor(n int) string {
+	if n < 8 {
+		return strconv.Itoa(30 + n)
+	}
+	return strconv.Itoa(90 + n - 8)
+}
+
+// ansi wraps s in an SGR escape sequence when color is on.
+func ansi(s string, color bool, sgr string) string {
+	if !color || s == "" {
+		return s
+	}
+	return "\x1b[" + sgr + "m" + s + "\x1b[0m"
+}
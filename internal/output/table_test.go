@@ -0,0 +1,149 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+func TestOutputTable(t *testing.T) {
+	counts := domain.CharCounts{
+		{Char: "世", Count: 4000, Percentage: 80},
+		{Char: "a", Count: 1000, Percentage: 20},
+	}
+
+	tests := []struct {
+		name     string
+		opts     TableOptions
+		bars     bool
+		colored  bool
+		maxWidth int
+	}{
+		{"auto on a terminal", TableOptions{Bars: TableAuto, Color: TableAuto, Terminal: true, Width: 60}, true, true, 60},
+		{"never on a terminal", TableOptions{Bars: TableNever, Color: TableNever, Terminal: true, Width: 60}, false, false, 35},
+		{"always bars", TableOptions{Bars: TableAlways, Color: TableNever, Width: 50}, true, false, 50},
+		{"too narrow for bars", TableOptions{Bars: TableAlways, Width: 40}, false, false, 35},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			outputter := NewOutputter(&buf)
+			outputter.Table = tt.opts
+			if err := outputter.OutputTable(counts, nil, true); err != nil {
+				t.Fatalf("OutputTable failed: %v", err)
+			}
+			got := buf.String()
+
+			if colored := strings.Contains(got, "\x1b["); colored != tt.colored {
+				t.Errorf("Expected colored=%v, got %q", tt.colored, got)
+			}
+			if bars := strings.Contains(got, "█"); bars != tt.bars {
+				t.Errorf("Expected bars=%v, got %q", tt.bars, got)
+			}
+
+			var counted []int
+			for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+				line = stripANSI(line)
+				if width := runewidth.StringWidth(line); width > tt.maxWidth {
+					t.Errorf("Line %q is %d cells wide, expected at most %d", line, width, tt.maxWidth)
+				}
+				if strings.Contains(line, "%") {
+					// The count column starts at the same cell on every row
+					counted = append(counted, runewidth.StringWidth(line[:strings.IndexAny(line, "0123456789")]))
+				}
+			}
+			if len(counted) != 2 || counted[0] != counted[1] {
+				t.Errorf("Expected aligned counts, got columns %v in %q", counted, got)
+			}
+		})
+	}
+}
+
+func TestOutputTablePlain(t *testing.T) {
+	counts := domain.CharCounts{
+		{Char: "a", Count: 4000, Percentage: 80},
+		{Char: "b", Count: 1000, Percentage: 20},
+	}
+	sequences := domain.SequenceCounts{
+		{Sequence: "ab", Count: 3, Percentage: 100},
+	}
+	expected := `Characters:
+-----------------------------------
+Character  Count      Percentage  
+-----------------------------------
+a          4000       80.00       %
+b          1000       20.00       %
+-----------------------------------
+
+Sequences (2-3 chars):
+-----------------------------------
+Sequence   Count      Percentage  
+-----------------------------------
+ab         3          100.00      %
+-----------------------------------
+`
+
+	var buf bytes.Buffer
+	outputter := NewOutputter(&buf)
+	outputter.Table = TableOptions{Bars: TableAuto, Color: TableAuto, Width: 60}
+	if err := outputter.OutputTable(counts, sequences, true); err != nil {
+		t.Fatalf("OutputTable failed: %v", err)
+	}
+	if buf.String() != expected {
+		t.Errorf("Expected the plain table off a terminal, got:\n%s", buf.String())
+	}
+
+	roots := []domain.RootResult{
+		{Path: "src", Weight: 2, FilesFound: 3, FilesIgnored: 1, TotalChars: 120, Share: 75},
+	}
+	expectedRoots := `
+Sources (weighted):
+-----------------------------------------------------
+Source Weight   Files    Characters   Share       
+-----------------------------------------------------
+src    2        2        120          75.00       %
+-----------------------------------------------------
+`
+
+	buf.Reset()
+	if err := outputter.OutputRootsTable(roots); err != nil {
+		t.Fatalf("OutputRootsTable failed: %v", err)
+	}
+	if buf.String() != expectedRoots {
+		t.Errorf("Expected the plain sources table off a terminal, got:\n%s", buf.String())
+	}
+}
+
+func TestBar(t *testing.T) {
+	tests := []struct {
+		length   float64
+		expected string
+	}{
+		{0, ""},
+		{0.5, "▌"},
+		{2, "██"},
+		{2.25, "██▎"},
+	}
+
+	for _, tt := range tests {
+		if got := bar(tt.length); got != tt.expected {
+			t.Errorf("bar(%v) = %q, expected %q", tt.length, got, tt.expected)
+		}
+	}
+}
+
+func stripANSI(s string) string {
+	for {
+		start := strings.Index(s, "\x1b[")
+		if start < 0 {
+			return s
+		}
+		end := strings.IndexByte(s[start:], 'm')
+		s = s[:start] + s[start+end+1:]
+	}
+}
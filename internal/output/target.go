@@ -0,0 +1,131 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+// Formats are the output formats Output knows.
+var Formats = []string{"table", "json", "ndjson", "csv", "markdown", "openmetrics", "html", "svg", "template"}
+
+// Stdout is the path of a Target that writes to standard output.
+const Stdout = "-"
+
+// Target is an output format and the file it is written to.
+type Target struct {
+	Format string
+	// Path is the file to write, or Stdout
+	Path string
+}
+
+// ParseTargets parses "format" and "format:path" specs. Formats without a
+// path are written to defaultPath, or to stdout when it is empty. Since the
+// outputs would run into each other, only one target may use a defaultPath
+// file and only one may write to stdout.
+func ParseTargets(specs []string, defaultPath string) ([]Target, error) {
+	if defaultPath == "" {
+		defaultPath = Stdout
+	}
+
+	targets := make([]Target, 0, len(specs))
+	usesDefault := 0
+	for _, spec := range specs {
+		format, path, hasPath := strings.Cut(spec, ":")
+		if !slices.Contains(Formats, format) {
+			return nil, fmt.Errorf("unknown format %q (%s)", format, strings.Join(Formats, ", "))
+		}
+		if hasPath && path == "" {
+			return nil, fmt.Errorf("missing path after %q", format+":")
+		}
+		if !hasPath {
+			path = defaultPath
+			usesDefault++
+		}
+		targets = append(targets, Target{Format: format, Path: path})
+	}
+
+	if usesDefault > 1 && defaultPath != Stdout {
+		return nil, fmt.Errorf("--output writes a single format; use format:path for the others")
+	}
+	stdout := 0
+	for _, target := range targets {
+		if target.Path == Stdout {
+			stdout++
+		}
+	}
+	if stdout > 1 {
+		return nil, fmt.Errorf("only one format can be written to stdout; use format:path for the others")
+	}
+	return targets, nil
+}
+
+// OutputTargets writes the result, narrowed by the Outputter's Filter, to
+// each target in its format. Stdout targets go to the Outputter's writer.
+func (o *Outputter) OutputTargets(
+	targets []Target,
+	result domain.AnalysisResult,
+	showPercentages bool,
+	directory string,
+	includeMetadata bool,
+) error {
+	result = o.Filter.Apply(result)
+	for _, target := range targets {
+		if err := o.outputTarget(target, result, showPercentages, directory, includeMetadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *Outputter) outputTarget(
+	target Target,
+	result domain.AnalysisResult,
+	showPercentages bool,
+	directory string,
+	includeMetadata bool,
+) error {
+	if s := o.streams[target]; s != nil {
+		// The file lines are already written, end them with the summary
+		to := *o
+		to.w = s.w
+		err := to.Output(target.Format, result, showPercentages, directory, includeMetadata)
+		if closeErr := o.closeStream(target); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+
+	write := func(w io.Writer, terminal bool) error {
+		buffered := bufio.NewWriter(w)
+		to := *o
+		to.w = buffered
+		to.Table.Terminal = terminal
+		if err := to.Output(target.Format, result, showPercentages, directory, includeMetadata); err != nil {
+			return err
+		}
+		return buffered.Flush()
+	}
+
+	if target.Path == Stdout || target.Path == "" {
+		return write(o.w, o.Table.Terminal)
+	}
+
+	file, err := os.Create(target.Path)
+	if err != nil {
+		return err
+	}
+	err = write(file, false)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write %s: %w", target.Path, err)
+	}
+	return nil
+}
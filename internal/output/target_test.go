@@ -0,0 +1,209 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+func TestParseTargets(t *testing.T) {
+	tests := []struct {
+		name        string
+		specs       []string
+		defaultPath string
+		expected    []Target
+		valid       bool
+	}{
+		{"stdout", []string{"table"}, "", []Target{{"table", Stdout}}, true},
+		{"output flag", []string{"json"}, "out.json", []Target{{"json", "out.json"}}, true},
+		{"pairs", []string{"json:out.json", "table:-"}, "", []Target{{"json", "out.json"}, {"table", Stdout}}, true},
+		{"output flag with pair", []string{"csv", "json:out.json"}, "out.csv", []Target{{"csv", "out.csv"}, {"json", "out.json"}}, true},
+		{"windows path", []string{`json:C:\out.json`}, "", []Target{{"json", `C:\out.json`}}, true},
+		{"two formats to one file", []string{"csv", "json"}, "out", nil, false},
+		{"two formats to stdout", []string{"csv", "json"}, "", nil, false},
+		{"two formats to stdout with a path", []string{"csv", "json:-"}, "", nil, false},
+		{"unknown format", []string{"yaml"}, "", nil, false},
+		{"empty path", []string{"json:"}, "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := ParseTargets(tt.specs, tt.defaultPath)
+			if (err == nil) != tt.valid {
+				t.Fatalf("Expected valid=%v, got error %v", tt.valid, err)
+			}
+			if tt.valid && !slices.Equal(targets, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, targets)
+			}
+		})
+	}
+}
+
+func TestOutputTargets(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "out.json")
+	result := domain.AnalysisResult{
+		CharCounts: domain.CharCounts{{Char: "a", Count: 2, Percentage: 100}},
+		TotalChars: 2,
+	}
+
+	var stdout bytes.Buffer
+	targets := []Target{{"json", jsonPath}, {"csv", Stdout}}
+	if err := NewOutputter(&stdout).OutputTargets(targets, result, true, dir, false); err != nil {
+		t.Fatalf("OutputTargets failed: %v", err)
+	}
+
+	if got := stdout.String(); !strings.HasPrefix(got, "type,sequence,count,percentage\ncharacter,a,2,100.00%") {
+		t.Errorf("Unexpected CSV on stdout: %q", got)
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("JSON target not written: %v", err)
+	}
+	var decoded domain.JSONOutput
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Result.Characters) != 1 {
+		t.Errorf("Unexpected JSON target %q: %v", data, err)
+	}
+
+	missing := []Target{{"json", filepath.Join(dir, "missing", "out.json")}}
+	if err := NewOutputter(&stdout).OutputTargets(missing, result, true, dir, false); err == nil {
+		t.Error("Expected an error for a target in a missing directory")
+	}
+
+	// Every format reports a failed write
+	for _, format := range Formats {
+		if format == "template" {
+			continue
+		}
+		err := NewOutputter(failingWriter{}).Output(format, result, true, dir, true)
+		if !errors.Is(err, errWriteFailed) {
+			t.Errorf("Expected the write error from %s, got %v", format, err)
+		}
+	}
+}
+
+var errWriteFailed = errors.New("write failed")
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errWriteFailed
+}
+
+func TestOutputTargetsKeepPercentages(t *testing.T) {
+	dir := t.TempDir()
+	result := domain.AnalysisResult{
+		CharCounts: domain.CharCounts{{Char: "a", Count: 2, Percentage: 40}, {Char: "b", Count: 3, Percentage: 60}},
+		Roots: []domain.RootResult{
+			{Path: "x", Weight: 1, Characters: domain.CharCounts{{Char: "a", Count: 2, Percentage: 40}}},
+		},
+		TotalChars: 5,
+	}
+
+	// The json target clears the percentages with -p=false, the svg target
+	// after it still labels its bars with them
+	var stdout bytes.Buffer
+	outputter := NewOutputter(&stdout)
+	outputter.Chart.Labels = ChartLabelPercentage
+	targets := []Target{{"json", filepath.Join(dir, "out.json")}, {"svg", Stdout}}
+	if err := outputter.OutputTargets(targets, result, false, dir, false); err != nil {
+		t.Fatalf("OutputTargets failed: %v", err)
+	}
+
+	if got := stdout.String(); !strings.Contains(got, "b: 3 (60.00%)") || !strings.Contains(got, "a: 2 (40.00%)") {
+		t.Errorf("Expected the svg target to keep the percentages, got %q", got)
+	}
+	if result.CharCounts[0].Percentage != 40 || result.Roots[0].Characters[0].Percentage != 40 {
+		t.Errorf("Expected the result to be left as it was, got %+v", result)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out.json"))
+	if err != nil {
+		t.Fatalf("JSON target not written: %v", err)
+	}
+	var decoded domain.JSONOutput
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Invalid JSON target: %v", err)
+	}
+	if decoded.Result.Characters[0].Percentage != 0 || decoded.Result.Roots[0].Characters[0].Percentage != 0 {
+		t.Errorf("Expected the json target without percentages, got %s", data)
+	}
+}
+
+func TestOutputStreams(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.ndjson")
+	result := domain.AnalysisResult{
+		CharCounts: domain.CharCounts{{Char: "a", Count: 3, Percentage: 100}},
+		TotalChars: 3,
+	}
+
+	var stdout bytes.Buffer
+	outputter := NewOutputter(&stdout)
+	targets := []Target{{"ndjson", path}, {"ndjson", Stdout}}
+	if err := outputter.OpenStreams(targets); err != nil {
+		t.Fatalf("OpenStreams failed: %v", err)
+	}
+	if !outputter.Streaming() {
+		t.Fatal("Expected the ndjson targets to stream")
+	}
+	outputter.OutputFile(domain.FileCounts{Path: "a.go", Chars: map[rune]int{'a': 1}, TotalChars: 1})
+	if !strings.HasPrefix(stdout.String(), `{"type":"file","path":"a.go"`) {
+		t.Errorf("Expected the file line on stdout before the result is complete, got %q", stdout.String())
+	}
+	outputter.OutputFile(domain.FileCounts{Path: "b", Chars: map[rune]int{'a': 2}, Sequences: map[string]int{"aa": 1}, TotalChars: 2})
+	if err := outputter.OutputTargets(targets, result, true, dir, false); err != nil {
+		t.Fatalf("OutputTargets failed: %v", err)
+	}
+	if outputter.Streaming() {
+		t.Error("Expected OutputTargets to close the streams")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ndjson target not written: %v", err)
+	}
+	if string(data) != stdout.String() {
+		t.Errorf("Expected the same lines in both targets, got %q and %q", data, stdout.String())
+	}
+
+	expected := []string{
+		`{"type":"file","path":"a.go","language":"Go","total_characters":1,"characters":{"a":1}}`,
+		`{"type":"file","path":"b","total_characters":2,"characters":{"a":2},"sequences":{"aa":1}}`,
+		`{"type":"summary","schema_version":2,"result":{"characters":[{"char":"a","count":3,"percentage":100}],"sequences":null}}`,
+	}
+	if lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); !slices.Equal(lines, expected) {
+		t.Errorf("Expected lines\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}
+
+func TestOutputStreamsFiltered(t *testing.T) {
+	var stdout bytes.Buffer
+	outputter := NewOutputter(&stdout)
+	outputter.Filter = Filter{Only: ClassLetters, MinCount: 5}
+	targets := []Target{{"ndjson", Stdout}}
+	if err := outputter.OpenStreams(targets); err != nil {
+		t.Fatalf("OpenStreams failed: %v", err)
+	}
+	outputter.OutputFile(domain.FileCounts{
+		Path:       "b",
+		Chars:      map[rune]int{'a': 2, '{': 1, ' ': 1},
+		Sequences:  map[string]int{"a{": 1, "{ ": 1},
+		TotalChars: 4,
+	})
+	outputter.CloseStreams()
+
+	// The class applies to file lines like to the summary; the limits do not
+	expected := `{"type":"file","path":"b","total_characters":4,"characters":{"a":2},"sequences":{"a{":1}}` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
@@ -0,0 +1,100 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+// TemplateData is what --template templates are executed with.
+type TemplateData struct {
+	// Directory is the analyzed directory, or the combined sources joined
+	// by ", "
+	Directory string
+	// ShowPercentages is false with --percentages=false, in which case all
+	// percentages are 0
+	ShowPercentages bool
+	// Characters are sorted by count, most used first. Each has .Char,
+	// .Count and .Percentage.
+	Characters domain.CharCounts
+	// Sequences are the sequences above the threshold, limited by
+	// --top-n-seq. Each has .Sequence, .Count and .Percentage.
+	Sequences domain.SequenceCounts
+	// Roots are each source's own counts when several were combined; see
+	// result.roots of the json format for the fields
+	Roots []domain.RootResult
+	// Metadata has the fields of the json format's metadata, such as
+	// .FilesProcessed, .TotalCharacters and .Timing.TotalDuration. It is nil
+	// with --metadata=false.
+	Metadata *domain.JSONMetadata
+}
+
+// templateFuncs are the functions templates can use besides the built-in
+// ones.
+var templateFuncs = template.FuncMap{
+	// name returns <space>, <tab>, <newline> and so on for whitespace
+	"name": charName,
+	// glyphs replaces whitespace with the symbols the TUI shows: ⎵ ⇥ ↵ ⏎
+	"glyphs": whitespaceGlyphs,
+	// percent formats a percentage with two decimals: 27.15%
+	"percent": func(percentage float64) string {
+		return fmt.Sprintf("%.2f%%", percentage)
+	},
+	// top returns the first n elements of a list, as in {{range top 10 .Characters}}
+	"top": func(n int, list any) (any, error) {
+		v := reflect.ValueOf(list)
+		if v.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("top of %T, expected a list", list)
+		}
+		return v.Slice(0, min(max(n, 0), v.Len())).Interface(), nil
+	},
+	// json encodes a value as JSON, such as a string for a JSON payload
+	"json": func(v any) (string, error) {
+		var b strings.Builder
+		encoder := json.NewEncoder(&b)
+		encoder.SetEscapeHTML(false)
+		err := encoder.Encode(v)
+		return strings.TrimSuffix(b.String(), "\n"), err
+	},
+}
+
+// ParseTemplate reads a text/template file for the template format.
+func ParseTemplate(path string) (*template.Template, error) {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// OutputTemplate executes the Outputter's Template with the result as
+// TemplateData.
+func (o *Outputter) OutputTemplate(
+	result domain.AnalysisResult,
+	showPercentages bool,
+	directory string,
+	includeMetadata bool,
+) error {
+	if o.Template == nil {
+		return fmt.Errorf("the template format needs a template, see --template")
+	}
+
+	output := o.jsonOutput(showPercentages, directory, result, includeMetadata)
+	data := TemplateData{
+		Directory:       directory,
+		ShowPercentages: showPercentages,
+		Characters:      output.Result.Characters,
+		Sequences:       output.Result.Sequences,
+		Roots:           output.Result.Roots,
+		Metadata:        output.Metadata,
+	}
+	if err := o.Template.Execute(o.w, data); err != nil {
+		return fmt.Errorf("could not execute template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogdakke/symbolista/internal/domain"
+)
+
+func TestOutputTemplate(t *testing.T) {
+	result := domain.AnalysisResult{
+		CharCounts: domain.CharCounts{
+			{Char: " ", Count: 3, Percentage: 50},
+			{Char: "{", Count: 2, Percentage: 33.333},
+			{Char: "a", Count: 1, Percentage: 16.667},
+		},
+		SequenceCounts: domain.SequenceCounts{{Sequence: "a\n", Count: 2, Percentage: 100}},
+		FilesFound:     2,
+		TotalChars:     6,
+	}
+
+	tests := []struct {
+		name            string
+		template        string
+		includeMetadata bool
+		expected        string
+	}{
+		{"fields", `{{.Directory}} {{len .Characters}} {{.ShowPercentages}}`, false, "src 3 true"},
+		{"name", `{{range .Characters}}{{name .Char}},{{end}}`, false, "<space>,{,a,"},
+		{"glyphs", `{{range .Sequences}}{{glyphs .Sequence}}{{end}}`, false, "a↵"},
+		{"percent", `{{range .Characters}}{{percent .Percentage}} {{end}}`, false, "50.00% 33.33% 16.67% "},
+		{"top", `{{range top 2 .Characters}}{{.Char}}{{end}}|{{len (top 10 .Characters)}}`, false, " {|3"},
+		{"json", `{{json (name "\"")}} {{json "<a>"}}`, false, `"\"" "<a>"`},
+		{"metadata", `{{with .Metadata}}{{.FilesProcessed}} {{.TotalCharacters}}{{else}}none{{end}}`, true, "2 6"},
+		{"no metadata", `{{with .Metadata}}{{.FilesProcessed}}{{else}}none{{end}}`, false, "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "report.tmpl")
+			if err := os.WriteFile(path, []byte(tt.template), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			tmpl, err := ParseTemplate(path)
+			if err != nil {
+				t.Fatalf("ParseTemplate failed: %v", err)
+			}
+
+			var buf bytes.Buffer
+			outputter := NewOutputter(&buf)
+			outputter.Template = tmpl
+			if err := outputter.OutputTemplate(result, true, "src", tt.includeMetadata); err != nil {
+				t.Fatalf("OutputTemplate failed: %v", err)
+			}
+			if got := buf.String(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOutputTemplateErrors(t *testing.T) {
+	if err := NewOutputter(&bytes.Buffer{}).OutputTemplate(domain.AnalysisResult{}, true, "", false); err == nil {
+		t.Error("Expected an error without a template")
+	}
+
+	path := filepath.Join(t.TempDir(), "broken.tmpl")
+	os.WriteFile(path, []byte("{{range .Characters}}"), 0o644)
+	if _, err := ParseTemplate(path); err == nil {
+		t.Error("Expected an error for an unclosed range")
+	}
+}
@@ -2,12 +2,13 @@ package traversal
 
 import (
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
-	"unicode/utf8"
 
 	"github.com/ogdakke/symbolista/internal/concurrent"
+	"github.com/ogdakke/symbolista/internal/extract"
 	"github.com/ogdakke/symbolista/internal/ignorer"
 	"github.com/ogdakke/symbolista/internal/logger"
 )
@@ -15,7 +16,14 @@ import (
 type FileProcessor func(path string, content []byte) error
 
 func WalkDirectory(rootPath string, matcher *ignorer.Matcher, processor FileProcessor) error {
-	return filepath.WalkDir(rootPath, func(path string, d os.DirEntry, err error) error {
+	return WalkFS(os.DirFS(rootPath), rootPath, matcher, processor)
+}
+
+// WalkFS walks fsys, which holds the tree rooted at rootPath. Paths passed to
+// the matcher and processor are joined to rootPath.
+func WalkFS(fsys fs.FS, rootPath string, matcher *ignorer.Matcher, processor FileProcessor) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		path := filepath.Join(rootPath, filepath.FromSlash(name))
 		if err != nil {
 			return err
 		}
@@ -27,39 +35,55 @@ func WalkDirectory(rootPath string, matcher *ignorer.Matcher, processor FileProc
 				}
 			}
 
-			if path != rootPath && matcher != nil && matcher.ShouldIgnore(path) {
-				logger.Debug("Skipping directory (gitignore)", "path", path)
-				return filepath.SkipDir
+			if name != "." && matcher != nil {
+				if decision := matcher.Decide(path, true); decision.Ignored {
+					logger.Debug("Skipping directory", "path", path, "reason", decision)
+					return filepath.SkipDir
+				}
 			}
 			logger.Trace("Entering directory", "path", path)
 			return nil
 		}
 
-		if d.Type()&os.ModeType != 0 {
-			logger.Debug("Skipping special file", "path", path, "mode", d.Type().String())
+		if decision := ignorer.DecideMode(d.Type()); decision.Ignored {
+			logger.Debug("Skipping file", "path", path, "reason", decision)
 			return nil
 		}
 
-		if matcher != nil && matcher.ShouldIgnore(path) {
-			logger.Debug("Skipping file (gitignore)", "path", path)
-			return nil
+		if matcher != nil {
+			if decision := matcher.Decide(path, false); decision.Ignored {
+				logger.Debug("Skipping file", "path", path, "reason", decision)
+				return nil
+			}
 		}
 
-		file, err := os.Open(path)
+		file, err := fsys.Open(name)
 		if err != nil {
 			logger.Debug("Cannot read file", "path", path, "error", err)
 			return nil
 		}
 		defer file.Close()
 
+		if matcher.SizeLimit() > 0 {
+			info, err := file.Stat()
+			if err != nil {
+				logger.Debug("Cannot stat file", "path", path, "error", err)
+				return nil
+			}
+			if decision := matcher.DecideSize(info.Size()); decision.Ignored {
+				logger.Debug("Skipping file", "path", path, "reason", decision)
+				return nil
+			}
+		}
+
 		content, err := io.ReadAll(file)
 		if err != nil {
 			logger.Debug("Cannot read file content", "path", path, "error", err)
 			return nil
 		}
 
-		if !utf8.Valid(content) {
-			logger.Debug("Skipping non-UTF8 file", "path", path)
+		if decision := ignorer.DecideContent(content); decision.Ignored {
+			logger.Debug("Skipping file", "path", path, "reason", decision)
 			return nil
 		}
 
@@ -80,6 +104,9 @@ type ConcurrentResult struct {
 	UniqueChars      int
 	UniqueSequences2 int
 	UniqueSequences3 int
+	// DuplicateFiles were skipped because their content was already counted
+	DuplicateFiles int
+	DuplicateBytes int64
 }
 
 // WalkDirectoryConcurrent processes files using a worker pool and returns aggregated results
@@ -89,8 +116,70 @@ func WalkDirectoryConcurrent(
 	workerCount int,
 	asciiOnly bool,
 	sequenceConfig concurrent.SequenceConfig,
+	extractors *extract.Registry,
+	deduper *concurrent.Deduper,
 	progressCallback concurrent.ProgressCallback,
+	fileCallback concurrent.FileCallback,
 ) (ConcurrentResult, error) {
+	return WalkFSConcurrent(os.DirFS(rootPath), rootPath, matcher, workerCount, asciiOnly, sequenceConfig, extractors, deduper, progressCallback, fileCallback)
+}
+
+// WalkFSConcurrent is WalkDirectoryConcurrent for a tree read through fsys,
+// such as an archive or a git revision, that is reported under rootPath
+func WalkFSConcurrent(
+	fsys fs.FS,
+	rootPath string,
+	matcher *ignorer.Matcher,
+	workerCount int,
+	asciiOnly bool,
+	sequenceConfig concurrent.SequenceConfig,
+	extractors *extract.Registry,
+	deduper *concurrent.Deduper,
+	progressCallback concurrent.ProgressCallback,
+	fileCallback concurrent.FileCallback,
+) (ConcurrentResult, error) {
+	return processConcurrent(workerCount, deduper, fileCallback, func(jobChan chan<- concurrent.FileJob, collector *concurrent.ResultCollector, errorCallback func(error)) {
+		concurrent.DiscoverFiles(fsys, rootPath, matcher, jobChan, asciiOnly, sequenceConfig, extractors, collector, progressCallback, errorCallback)
+	})
+}
+
+// ProcessFilesConcurrent processes an explicit list of files using a worker pool
+// and returns aggregated results
+func ProcessFilesConcurrent(
+	paths []string,
+	matcher *ignorer.Matcher,
+	workerCount int,
+	asciiOnly bool,
+	sequenceConfig concurrent.SequenceConfig,
+	extractors *extract.Registry,
+	deduper *concurrent.Deduper,
+	progressCallback concurrent.ProgressCallback,
+	fileCallback concurrent.FileCallback,
+) (ConcurrentResult, error) {
+	return processConcurrent(workerCount, deduper, fileCallback, func(jobChan chan<- concurrent.FileJob, collector *concurrent.ResultCollector, errorCallback func(error)) {
+		concurrent.DiscoverFileList(paths, matcher, jobChan, asciiOnly, sequenceConfig, extractors, collector, progressCallback, errorCallback)
+	})
+}
+
+// ProcessContentsConcurrent counts content that was already read, such as
+// lines added in a diff, using a worker pool and returns aggregated results
+func ProcessContentsConcurrent(
+	contents []concurrent.Content,
+	matcher *ignorer.Matcher,
+	workerCount int,
+	asciiOnly bool,
+	sequenceConfig concurrent.SequenceConfig,
+	progressCallback concurrent.ProgressCallback,
+	fileCallback concurrent.FileCallback,
+) (ConcurrentResult, error) {
+	return processConcurrent(workerCount, nil, fileCallback, func(jobChan chan<- concurrent.FileJob, collector *concurrent.ResultCollector, errorCallback func(error)) {
+		concurrent.DiscoverContents(contents, matcher, jobChan, asciiOnly, sequenceConfig, collector, progressCallback, errorCallback)
+	})
+}
+
+type discoverFunc func(jobChan chan<- concurrent.FileJob, collector *concurrent.ResultCollector, errorCallback func(error))
+
+func processConcurrent(workerCount int, deduper *concurrent.Deduper, fileCallback concurrent.FileCallback, discover discoverFunc) (ConcurrentResult, error) {
 	if workerCount <= 0 {
 		workerCount = runtime.NumCPU()
 	}
@@ -98,18 +187,22 @@ func WalkDirectoryConcurrent(
 	bufferSize := workerCount * 2
 
 	pool := concurrent.NewWorkerPool(workerCount, bufferSize)
+	pool.SetDeduper(deduper)
 	collector := concurrent.NewResultCollector()
 
 	pool.Start()
 
 	var discoveryError error
-	go concurrent.DiscoverFiles(rootPath, matcher, pool.Jobs(), asciiOnly, sequenceConfig, collector, progressCallback, func(err error) {
+	go discover(pool.Jobs(), collector, func(err error) {
 		if discoveryError == nil {
 			discoveryError = err
 		}
 	})
 
 	for result := range pool.Results() {
+		if fileCallback != nil && !result.Duplicate {
+			fileCallback(result)
+		}
 		collector.AddResult(result)
 	}
 
@@ -120,11 +213,13 @@ func WalkDirectoryConcurrent(
 	}
 
 	charMap, sequenceMap2, sequenceMap3, fileCount, totalChars, filesFound, filesIgnored, timing := collector.GetResults()
+	duplicateFiles, duplicateBytes := collector.GetDuplicates()
 
 	logger.Info("Concurrent processing completed",
 		"files_processed", fileCount,
 		"files_found", filesFound,
 		"files_ignored", filesIgnored,
+		"duplicate_files", duplicateFiles,
 		"total_characters", totalChars,
 		"unique_characters", len(charMap),
 		"workers", workerCount,
@@ -142,5 +237,7 @@ func WalkDirectoryConcurrent(
 		UniqueChars:      len(charMap),
 		UniqueSequences2: len(sequenceMap2),
 		UniqueSequences3: len(sequenceMap3),
+		DuplicateFiles:   duplicateFiles,
+		DuplicateBytes:   duplicateBytes,
 	}, nil
 }
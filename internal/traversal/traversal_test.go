@@ -3,6 +3,7 @@ package traversal
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -474,3 +475,93 @@ func TestProcessorError(t *testing.T) {
 		t.Error("Expected error from processor")
 	}
 }
+
+func TestWalkDirectoryWithExtensionRules(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "traversal_extension_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	srcDir := filepath.Join(tempDir, "src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatalf("Failed to create src dir: %v", err)
+	}
+
+	files := []string{
+		filepath.Join(tempDir, "main.go"),
+		filepath.Join(tempDir, "main_test.go"),
+		filepath.Join(tempDir, "README.md"),
+		filepath.Join(tempDir, "fixture.json"),
+		filepath.Join(srcDir, "app.ts"),
+		filepath.Join(srcDir, "app.spec.ts"),
+		filepath.Join(srcDir, "logo.svg"),
+	}
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     ignorer.Options
+		expected []string
+	}{
+		{
+			name:     "allowlist",
+			opts:     ignorer.Options{Extensions: []string{"go", ".TS"}},
+			expected: []string{"app.spec.ts", "app.ts", "main.go", "main_test.go"},
+		},
+		{
+			name:     "denylist",
+			opts:     ignorer.Options{ExcludeExtensions: []string{"md", "json"}},
+			expected: []string{"app.spec.ts", "app.ts", "main.go", "main_test.go"},
+		},
+		{
+			name:     "allowlist overrides default svg rule",
+			opts:     ignorer.Options{Extensions: []string{"svg"}},
+			expected: []string{"logo.svg"},
+		},
+		{
+			name:     "code-only and no-tests presets",
+			opts:     ignorer.Options{Presets: []string{"code-only", "no-tests"}},
+			expected: []string{"app.ts", "main.go"},
+		},
+		{
+			name:     "no-docs and no-data presets",
+			opts:     ignorer.Options{Presets: []string{"no-docs", "no-data"}},
+			expected: []string{"app.spec.ts", "app.ts", "main.go", "main_test.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := ignorer.NewMatcherWithOptions(tempDir, tt.opts)
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+
+			var processed []string
+			err = WalkDirectory(tempDir, matcher, func(path string, content []byte) error {
+				processed = append(processed, filepath.Base(path))
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("WalkDirectory failed: %v", err)
+			}
+
+			slices.Sort(processed)
+			if !slices.Equal(processed, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, processed)
+			}
+		})
+	}
+}
+
+func TestUnknownPreset(t *testing.T) {
+	_, err := ignorer.NewMatcherWithOptions(t.TempDir(), ignorer.Options{Presets: []string{"nope"}})
+	if err == nil {
+		t.Error("Expected error for unknown preset")
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/NimbleMarkets/ntcharts/barchart"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/ogdakke/symbolista/internal/concurrent"
 	"github.com/ogdakke/symbolista/internal/counter"
 	"github.com/ogdakke/symbolista/internal/domain"
 	"github.com/ogdakke/symbolista/internal/logger"
@@ -43,10 +42,7 @@ const (
 type Model struct {
 	directory       string
 	showPercentages bool
-	workerCount     int
-	includeDotfiles bool
-	asciiOnly       bool
-	topNSeq         int
+	options         counter.Options
 	countSeq        bool
 
 	charCounts        domain.CharCounts
@@ -252,24 +248,17 @@ func (v ViewMode) String() string {
 func NewModel(
 	directory string,
 	showPercentages bool,
-	workerCount int,
-	includeDotfiles bool,
-	asciiOnly bool,
-	topNSeq int,
-	countSeq bool,
+	options counter.Options,
 ) Model {
 	return Model{
 		directory:         directory,
 		showPercentages:   showPercentages,
-		workerCount:       workerCount,
-		includeDotfiles:   includeDotfiles,
-		asciiOnly:         asciiOnly,
-		topNSeq:           topNSeq,
+		options:           options,
 		loading:           true,
 		filterMode:        FilterAll,
 		viewMode:          ViewCharacters,
 		excludeWhitespace: true,
-		countSeq:          countSeq,
+		countSeq:          options.SequenceConfig.Enabled,
 	}
 }
 
@@ -324,7 +313,7 @@ func (m Model) Init() tea.Cmd {
 		return tea.EnterAltScreen
 	}
 	return tea.Batch(
-		startAnalysis(m.directory, m.workerCount, m.includeDotfiles, m.asciiOnly, m.topNSeq, m.countSeq),
+		startAnalysis(m.directory, m.options),
 		tea.EnterAltScreen,
 	)
 }
@@ -352,11 +341,7 @@ func listenForCompletion(doneChan <-chan analysisCompleteMsg) tea.Cmd {
 
 func startAnalysis(
 	directory string,
-	workerCount int,
-	includeDotfiles bool,
-	asciiOnly bool,
-	topNSeq int,
-	countSeq bool,
+	options counter.Options,
 ) tea.Cmd {
 	return func() tea.Msg {
 		logger.Info("Starting async TUI analysis", "directory", directory)
@@ -379,21 +364,10 @@ func startAnalysis(
 				}
 			}
 
-			sequenceConfig := concurrent.SequenceConfig{
-				Enabled:   countSeq,
-				MinLength: 2,
-				MaxLength: 3,
-				Threshold: 2,
-			}
-
 			result, err := counter.AnalyzeSymbols(
 				directory,
-				workerCount,
-				includeDotfiles,
-				asciiOnly,
-				sequenceConfig,
+				options,
 				progressFunc,
-				topNSeq,
 			)
 
 			doneChan <- analysisCompleteMsg{
@@ -460,7 +434,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.ready {
 				m.loading = true
 				m.ready = false
-				return m, startAnalysis(m.directory, m.workerCount, m.includeDotfiles, m.asciiOnly, m.topNSeq, m.countSeq)
+				return m, startAnalysis(m.directory, m.options)
 			}
 
 		case "f":
@@ -1,24 +1,20 @@
 package tui
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ogdakke/symbolista/internal/counter"
 	"github.com/ogdakke/symbolista/internal/domain"
 )
 
 func RunTUI(
 	directory string,
 	showPercentages bool,
-	workerCount int,
-	includeDotfiles bool,
-	asciiOnly bool,
-	topNSeq int,
-	countSeq bool,
+	options counter.Options,
 ) error {
-	model := NewModel(directory, showPercentages, workerCount, includeDotfiles, asciiOnly, topNSeq, countSeq)
+	model := NewModel(directory, showPercentages, options)
 
 	p := tea.NewProgram(
 		model,
@@ -36,8 +32,8 @@ func RunTUIFromJSON(jsonFile string) error {
 		return fmt.Errorf("failed to read JSON file: %w", err)
 	}
 
-	var jsonOutput domain.JSONOutput
-	if err := json.Unmarshal(data, &jsonOutput); err != nil {
+	jsonOutput, err := domain.ParseJSONOutput(data)
+	if err != nil {
 		return fmt.Errorf("failed to parse JSON file: %w", err)
 	}
 
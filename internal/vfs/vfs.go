@@ -0,0 +1,225 @@
+// Package vfs provides a read-only io/fs.FS built from a list of files, for
+// sources that are not a directory on disk, such as archives and git
+// revisions. File contents are read on demand.
+package vfs
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReadFunc returns the content of one file.
+type ReadFunc func() ([]byte, error)
+
+type node struct {
+	name     string
+	size     int64
+	mode     fs.FileMode
+	modTime  time.Time
+	read     ReadFunc
+	children []*node
+}
+
+// FS is a read-only file system of added files. Parent directories are
+// created implicitly. All files must be added before the FS is used.
+type FS struct {
+	nodes    map[string]*node
+	sortOnce sync.Once
+}
+
+func New() *FS {
+	return &FS{nodes: map[string]*node{
+		".": {name: ".", mode: fs.ModeDir | 0555},
+	}}
+}
+
+// Add adds a file. The mode type must be 0 for regular files; symlinks and
+// other special files can be added with their mode and a nil read func, so
+// that a walk sees and skips them. Invalid paths are skipped and reported
+// as false.
+func (f *FS) Add(name string, size int64, mode fs.FileMode, modTime time.Time, read ReadFunc) bool {
+	name, ok := cleanPath(name)
+	if !ok {
+		return false
+	}
+	if existing, ok := f.nodes[name]; ok && existing.mode.IsDir() {
+		return false
+	}
+
+	f.put(name, &node{name: path.Base(name), size: size, mode: mode, modTime: modTime, read: read})
+	return true
+}
+
+// AddDir adds a directory, for archives that list empty directories.
+func (f *FS) AddDir(name string, modTime time.Time) {
+	if name, ok := cleanPath(name); ok {
+		f.dir(name).modTime = modTime
+	}
+}
+
+func cleanPath(name string) (string, bool) {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	return name, fs.ValidPath(name) && name != "."
+}
+
+// dir returns the directory node for name, creating it and its parents as
+// needed. A file with the same name is replaced.
+func (f *FS) dir(name string) *node {
+	if existing, ok := f.nodes[name]; ok && existing.mode.IsDir() {
+		return existing
+	}
+	dir := &node{name: path.Base(name), mode: fs.ModeDir | 0555}
+	f.put(name, dir)
+	return dir
+}
+
+func (f *FS) put(name string, n *node) {
+	parent := f.dir(path.Dir(name))
+	if _, exists := f.nodes[name]; exists {
+		for i, child := range parent.children {
+			if child.name == n.name {
+				parent.children[i] = n
+			}
+		}
+	} else {
+		parent.children = append(parent.children, n)
+	}
+	f.nodes[name] = n
+}
+
+func (f *FS) sortAll() {
+	for _, n := range f.nodes {
+		slices.SortFunc(n.children, func(a, b *node) int { return strings.Compare(a.name, b.name) })
+	}
+}
+
+func (f *FS) lookup(op, name string) (*node, error) {
+	f.sortOnce.Do(f.sortAll)
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	n, ok := f.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return n, nil
+}
+
+func (f *FS) Open(name string) (fs.File, error) {
+	n, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.mode.IsDir() {
+		return &openDir{node: n, entries: n.entries()}, nil
+	}
+	return &openFile{node: n, name: name}, nil
+}
+
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	n, err := f.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	if n.read == nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrPermission}
+	}
+	return n.read()
+}
+
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !n.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	return n.entries(), nil
+}
+
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	n, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{n}, nil
+}
+
+func (n *node) entries() []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(n.children))
+	for i, child := range n.children {
+		entries[i] = fs.FileInfoToDirEntry(fileInfo{child})
+	}
+	return entries
+}
+
+type fileInfo struct {
+	node *node
+}
+
+func (i fileInfo) Name() string       { return i.node.name }
+func (i fileInfo) Size() int64        { return i.node.size }
+func (i fileInfo) Mode() fs.FileMode  { return i.node.mode }
+func (i fileInfo) ModTime() time.Time { return i.node.modTime }
+func (i fileInfo) IsDir() bool        { return i.node.mode.IsDir() }
+func (i fileInfo) Sys() any           { return nil }
+
+type openFile struct {
+	node   *node
+	name   string
+	reader *bytes.Reader
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return fileInfo{f.node}, nil }
+func (f *openFile) Close() error               { return nil }
+
+func (f *openFile) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		if f.node.read == nil {
+			return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrPermission}
+		}
+		content, err := f.node.read()
+		if err != nil {
+			return 0, err
+		}
+		f.reader = bytes.NewReader(content)
+	}
+	return f.reader.Read(p)
+}
+
+type openDir struct {
+	node    *node
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) { return fileInfo{d.node}, nil }
+func (d *openDir) Close() error               { return nil }
+
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.node.name, Err: fs.ErrInvalid}
+}
+
+func (d *openDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if count <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	count = min(count, len(remaining))
+	d.offset += count
+	return remaining[:count], nil
+}
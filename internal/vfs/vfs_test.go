@@ -0,0 +1,65 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func content(s string) ReadFunc {
+	return func() ([]byte, error) { return []byte(s), nil }
+}
+
+func TestFS(t *testing.T) {
+	fsys := New()
+	fsys.Add("b.go", 1, 0644, time.Time{}, content("b"))
+	fsys.Add("src/z.go", 1, 0644, time.Time{}, content("z"))
+	fsys.Add("/src/a.go", 1, 0644, time.Time{}, content("a"))
+	fsys.Add("src/nested/../c.go", 1, 0644, time.Time{}, content("c"))
+	fsys.AddDir("empty", time.Time{})
+
+	if fsys.Add("../outside.go", 1, 0644, time.Time{}, content("x")) {
+		t.Error("Expected a path outside the root to be rejected")
+	}
+	if fsys.Add("src", 1, 0644, time.Time{}, content("x")) {
+		t.Error("Expected a file replacing a directory to be rejected")
+	}
+
+	if err := fstest.TestFS(fsys, "b.go", "src/a.go", "src/c.go", "src/z.go", "empty"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := fs.ReadDir(fsys, "src")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if !slices.Equal(names, []string{"a.go", "c.go", "z.go"}) {
+		t.Errorf("Expected sorted entries, got %v", names)
+	}
+}
+
+func TestFSUnreadable(t *testing.T) {
+	fsys := New()
+	fsys.Add("link", 0, fs.ModeSymlink|0777, time.Time{}, nil)
+
+	info, err := fs.Stat(fsys, "link")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Type() != fs.ModeSymlink {
+		t.Errorf("Expected a symlink, got %v", info.Mode())
+	}
+	if _, err := fs.ReadFile(fsys, "link"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Expected fs.ErrPermission, got %v", err)
+	}
+	if _, err := fs.ReadFile(fsys, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+}
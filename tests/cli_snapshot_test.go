@@ -81,6 +81,39 @@ func TestCLISnapshots(t *testing.T) {
 			name: "sequence_analysis_csv",
 			args: []string{"--format=csv"},
 		},
+		{
+			name: "basic_analysis_markdown",
+			args: []string{"--format=markdown", "--metadata=false"},
+		},
+		{
+			name: "basic_analysis_html",
+			args: []string{"--format=html", "--metadata=false"},
+		},
+		{
+			name: "svg_bars",
+			args: []string{"--format=svg"},
+		},
+		{
+			name: "svg_pareto_sequences",
+			args: []string{"--format=svg", "--chart=pareto", "--chart-items=sequences", "--chart-labels=percentage", "--chart-top=12"},
+		},
+		{
+			// One worker counts the files in walk order
+			name: "ndjson_single_worker",
+			args: []string{"--format=ndjson", "--metadata=false", "--workers=1"},
+		},
+		{
+			name: "openmetrics",
+			args: []string{"--format=openmetrics", "--metadata=false"},
+		},
+		{
+			name: "template_slack",
+			args: []string{"--template=../examples/templates/slack.json.tmpl", "--metadata=false"},
+		},
+		{
+			name: "filter_symbols_relative",
+			args: []string{"--format=csv", "--only=symbols", "--relative-percentages", "--top-n-chars=10", "--sort=codepoint"},
+		},
 	}
 
 	for _, tt := range tests {